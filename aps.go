@@ -0,0 +1,99 @@
+package xinge
+
+import "fmt"
+
+// iOS 15 起通知的打断级别，对应 aps 中的 interruption-level
+const (
+	IOS_INTERRUPTION_PASSIVE        = "passive"
+	IOS_INTERRUPTION_ACTIVE         = "active"
+	IOS_INTERRUPTION_TIME_SENSITIVE = "time-sensitive"
+	IOS_INTERRUPTION_CRITICAL       = "critical"
+)
+
+// APNs 的 alert 字典
+type ApsAlert struct {
+	Title           string   `json:"title,omitempty"`
+	Subtitle        string   `json:"subtitle,omitempty"`
+	Body            string   `json:"body,omitempty"`
+	LaunchImage     string   `json:"launch-image,omitempty"`
+	TitleLocKey     string   `json:"title-loc-key,omitempty"`
+	TitleLocArgs    []string `json:"title-loc-args,omitempty"`
+	SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`
+	SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"`
+	LocKey          string   `json:"loc-key,omitempty"`
+	LocArgs         []string `json:"loc-args,omitempty"`
+}
+
+func NewApsAlert(title, body string) *ApsAlert {
+	return &ApsAlert{Title: title, Body: body}
+}
+
+// 校验 alert 字典：标题、正文或 loc-key 至少有一项，各 loc-args 需要对应的 loc-key
+func (s *ApsAlert) Validate() error {
+	var errs ValidationErrors
+	if s.Title == "" && s.Body == "" && s.TitleLocKey == "" && s.LocKey == "" {
+		errs.add("", "one of title, body, title-loc-key or loc-key is required")
+	}
+	if len(s.TitleLocArgs) > 0 && s.TitleLocKey == "" {
+		errs.add("title-loc-args", "requires title-loc-key")
+	}
+	if len(s.SubtitleLocArgs) > 0 && s.SubtitleLocKey == "" {
+		errs.add("subtitle-loc-args", "requires subtitle-loc-key")
+	}
+	if len(s.LocArgs) > 0 && s.LocKey == "" {
+		errs.add("loc-args", "requires loc-key")
+	}
+	return errs.err()
+}
+
+// APNs 的 sound 字典，用于重要警告（critical alert）
+type ApsSound struct {
+	Critical int     `json:"critical"`
+	Name     string  `json:"name"`
+	Volume   float64 `json:"volume"`
+}
+
+// 重要警告提示音，volume 取值 0~1
+func NewCriticalSound(name string, volume float64) *ApsSound {
+	return &ApsSound{Critical: 1, Name: name, Volume: volume}
+}
+
+// 校验 sound 字典：name 必填，volume 在 0~1 之间
+func (s *ApsSound) Validate() error {
+	var errs ValidationErrors
+	checkSwitch(&errs, "critical", s.Critical)
+	if s.Name == "" {
+		errs.add("name", "required")
+	}
+	if s.Volume < 0 || s.Volume > 1 {
+		errs.add("volume", fmt.Sprintf("must be between 0 and 1, got %g", s.Volume))
+	}
+	return errs.err()
+}
+
+// 校验 aps 字典中的字段，结构化消息和 Raw 消息共用
+func (s *MessageIOS) validateAps(errs *ValidationErrors) {
+	if s.Type == TYPE_APNS_NOTIFICATION {
+		if s.Alert != nil {
+			errs.addNested("aps.alert", s.Alert.Validate())
+		} else if s.AlertStr == "" && len(s.AlertJo) > 0 {
+			errs.add("aps.alert", "alert_jo is not sent, use Alert or AlertStr")
+		} else if s.AlertStr == "" {
+			errs.add("aps.alert", "required for APNS notification")
+		}
+	}
+
+	if s.CriticalSound != nil {
+		errs.addNested("aps.sound", s.CriticalSound.Validate())
+	}
+
+	switch s.InterruptionLevel {
+	case "", IOS_INTERRUPTION_PASSIVE, IOS_INTERRUPTION_ACTIVE, IOS_INTERRUPTION_TIME_SENSITIVE, IOS_INTERRUPTION_CRITICAL:
+	default:
+		errs.add("aps.interruption-level", fmt.Sprintf("unknown level %q", s.InterruptionLevel))
+	}
+
+	if s.RelevanceScore != nil && (*s.RelevanceScore < 0 || *s.RelevanceScore > 1) {
+		errs.add("aps.relevance-score", fmt.Sprintf("must be between 0 and 1, got %g", *s.RelevanceScore))
+	}
+}
@@ -0,0 +1,240 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	ACCOUNT_LIST_MAX_SIZE     = 100   // PushAccountList 单次最多账号数
+	ACCOUNT_LIST_MAX_TOTAL    = 10000 // 超过此数量时改用 CreateMultipush + PushAccountListMultiple
+	MULTIPLE_LIST_MAX_SIZE    = 1000  // PushAccountListMultiple / PushDeviceListMultiple 单次最多数量
+	DEFAULT_BATCH_CONCURRENCY = 4
+)
+
+// 设置 PushToAccounts、PushToDevices、SetTags、模板推送等批量接口同时发送的请求数，默认 DEFAULT_BATCH_CONCURRENCY
+func WithBatchConcurrency(n int) Option {
+	return func(c *Client) {
+		c.batchConcurrency = n
+	}
+}
+
+// 批量推送中一次请求的结果
+type ChunkResult struct {
+	Endpoint string   // 调用的接口，RESTAPI_* 之一
+	Targets  []string // 本次请求的账号或设备
+	Response *XgResponse
+	Err      error
+}
+
+// 批量推送的结果，每次请求对应一个 ChunkResult
+type BatchResult struct {
+	PushId int64 // 走 CreateMultipush 时的 push_id，否则为 0
+	Chunks []*ChunkResult
+}
+
+// 所有请求都成功
+func (r *BatchResult) Success() bool {
+	return r.Err() == nil
+}
+
+// 汇总各请求的错误
+func (r *BatchResult) Err() error {
+	var errs []error
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			errs = append(errs, chunk.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 推送失败的账号或设备，可用于重试
+func (r *BatchResult) FailedTargets() []string {
+	failed := make([]string, 0)
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			failed = append(failed, chunk.Targets...)
+		}
+	}
+	return failed
+}
+
+/**
+ * 推送给任意数量的账号，自动去重并按数量选择接口：
+ * 1 个账号调用 PushSingleAccount；不超过 ACCOUNT_LIST_MAX_TOTAL 个时按 ACCOUNT_LIST_MAX_SIZE 分批调用 PushAccountList；
+ * 更多时调用 CreateMultipush，再按 MULTIPLE_LIST_MAX_SIZE 分批调用 PushAccountListMultiple。
+ * 各批次并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param accounts 目标账号，重复和空的账号会被忽略
+ * @param message 待推送的消息，走 CreateMultipush 时不支持定时和循环推送
+ * @return 每个批次的结果；参数不合法或 CreateMultipush 失败时不发送任何批次，返回 nil 和错误；
+ *         部分批次失败时返回结果和各批次错误的汇总
+ */
+func (c *Client) PushToAccounts(ctx context.Context, accounts []string, message Message) (*BatchResult, error) {
+	accounts, err := c.prepareBatch("accounts", accounts, message, ACCOUNT_LIST_MAX_TOTAL+1)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := c.Checked()
+	switch {
+	case len(accounts) == 1:
+		return c.sendChunks(ctx, 0, RESTAPI_PUSHSINGLEACCOUNT, accounts, 1, func(chunk []string) (*XgResponse, error) {
+			return cc.PushSingleAccount(ctx, chunk[0], message)
+		})
+	case len(accounts) <= ACCOUNT_LIST_MAX_TOTAL:
+		return c.sendChunks(ctx, 0, RESTAPI_PUSHACCOUNTLIST, accounts, ACCOUNT_LIST_MAX_SIZE, func(chunk []string) (*XgResponse, error) {
+			return cc.PushAccountList(ctx, chunk, message)
+		})
+	}
+
+	pushId, err := c.createMultipush(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendChunks(ctx, pushId, RESTAPI_PUSHACCOUNTLISTMULTIPLE, accounts, MULTIPLE_LIST_MAX_SIZE, func(chunk []string) (*XgResponse, error) {
+		return cc.PushAccountListMultiple(ctx, pushId, chunk)
+	})
+}
+
+/**
+ * 推送给任意数量的设备，自动去重并按数量选择接口：
+ * 1 个设备调用 PushSingleDevice；更多时调用 CreateMultipush，再按 MULTIPLE_LIST_MAX_SIZE 分批调用 PushDeviceListMultiple。
+ * 各批次并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param tokens 目标设备 token，重复和空的 token 会被忽略
+ * @param message 待推送的消息，多个设备时走 CreateMultipush，不支持定时和循环推送
+ * @return 每个批次的结果；参数不合法或 CreateMultipush 失败时不发送任何批次，返回 nil 和错误；
+ *         部分批次失败时返回结果和各批次错误的汇总
+ */
+func (c *Client) PushToDevices(ctx context.Context, tokens []string, message Message) (*BatchResult, error) {
+	tokens, err := c.prepareBatch("tokens", tokens, message, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := c.Checked()
+	if len(tokens) == 1 {
+		return c.sendChunks(ctx, 0, RESTAPI_PUSHSINGLEDEVICE, tokens, 1, func(chunk []string) (*XgResponse, error) {
+			return cc.PushSingleDevice(ctx, chunk[0], message)
+		})
+	}
+
+	pushId, err := c.createMultipush(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendChunks(ctx, pushId, RESTAPI_PUSHDEVICELISTMULTIPLE, tokens, MULTIPLE_LIST_MAX_SIZE, func(chunk []string) (*XgResponse, error) {
+		return cc.PushDeviceListMultiple(ctx, pushId, chunk)
+	})
+}
+
+// 去重并在发送前校验消息，避免每个批次重复失败；目标数不少于 multipushFrom 时走 CreateMultipush
+func (c *Client) prepareBatch(field string, targets []string, message Message, multipushFrom int) ([]string, error) {
+	if _, err := c.validateMessageType(message); err != nil {
+		return nil, err
+	}
+	if err := message.Validate(); err != nil {
+		return nil, err
+	}
+
+	targets = dedupe(targets)
+	if len(targets) == 0 {
+		return nil, newValidationError(field, "empty list")
+	}
+	if len(targets) >= multipushFrom {
+		if err := checkMultipushMessage(message); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// CreateMultipush 创建的任务不支持定时和循环推送，拒绝这类消息以免定时设置被忽略
+func checkMultipushMessage(message Message) error {
+	var errs ValidationErrors
+	if t, err := time.ParseInLocation(DATETIMEFORMAT, message.GetSendTime(), time.Local); err == nil && t.After(time.Now()) {
+		errs.add("send_time", "timing push is not supported by create_multipush")
+	}
+	if message.GetLoopInterval() > 0 && message.GetLoopTimes() > 0 {
+		errs.add("loop_interval", "loop push is not supported by create_multipush")
+	}
+	return errs.err()
+}
+
+// 创建大批量推送任务，返回 push_id
+func (c *Client) createMultipush(ctx context.Context, message Message) (int64, error) {
+	res, err := c.Checked().CreateMultipush(ctx, message)
+	if err != nil {
+		return 0, err
+	}
+	if res.XgResult == nil || res.XgResult.PushId <= 0 {
+		return 0, &APIError{Code: res.Code, Msg: "create multipush returned no push_id"}
+	}
+	return res.XgResult.PushId, nil
+}
+
+// 按 size 切分 targets，以 Client 配置的并发数调用 send
+func (c *Client) sendChunks(ctx context.Context, pushId int64, endpoint string, targets []string, size int, send func([]string) (*XgResponse, error)) (*BatchResult, error) {
+	res := &BatchResult{PushId: pushId}
+	for start := 0; start < len(targets); start += size {
+		end := start + size
+		if end > len(targets) {
+			end = len(targets)
+		}
+		res.Chunks = append(res.Chunks, &ChunkResult{Endpoint: endpoint, Targets: targets[start:end]})
+	}
+
+	c.runBounded(ctx, len(res.Chunks), func(i int) {
+		chunk := res.Chunks[i]
+		chunk.Response, chunk.Err = send(chunk.Targets)
+	}, func(i int, err error) {
+		res.Chunks[i].Err = err
+	})
+	return res, res.Err()
+}
+
+// 以 Client 配置的并发数执行 run(0) ~ run(n-1)，ctx 结束后剩余的调用不再执行，改为调用 skip
+func (c *Client) runBounded(ctx context.Context, n int, run func(i int), skip func(i int, err error)) {
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = DEFAULT_BATCH_CONCURRENCY
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			skip(i, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// 按首次出现的顺序去重，并去掉空字符串
+func dedupe(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	unique := make([]string, 0, len(list))
+	for _, v := range list {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		unique = append(unique, v)
+	}
+	return unique
+}
@@ -0,0 +1,155 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/panjunjie/xinge"
+)
+
+// 记录同时进行中的请求数
+type concurrencyTransport struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (t *concurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.inFlight++
+	if t.inFlight > t.max {
+		t.max = t.inFlight
+	}
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func makeTargets(prefix string, n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return list
+}
+
+func TestPushToAccountsPaths(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.BindAccount(testAccountName, testTokenA)
+	msg := EasyMessageAndroid("title", "content")
+	ctx := context.Background()
+
+	res, err := c.PushToAccounts(ctx, []string{testAccountName, "", testAccountName}, msg)
+	if err != nil || len(res.Chunks) != 1 || res.Chunks[0].Endpoint != RESTAPI_PUSHSINGLEACCOUNT {
+		t.Fatalf("single account: %+v, %v", res, err)
+	}
+
+	accounts := makeTargets("user", 250)
+	res, err = c.PushToAccounts(ctx, append(accounts, accounts[:10]...), msg)
+	if err != nil {
+		t.Fatalf("account list: %v", err)
+	}
+	if len(res.Chunks) != 3 || len(res.Chunks[2].Targets) != 50 || res.Chunks[0].Endpoint != RESTAPI_PUSHACCOUNTLIST || res.PushId != 0 {
+		t.Errorf("account list chunks = %d, last %d", len(res.Chunks), len(res.Chunks[2].Targets))
+	}
+
+	res, err = c.PushToAccounts(ctx, makeTargets("user", ACCOUNT_LIST_MAX_TOTAL+1), msg)
+	if err != nil {
+		t.Fatalf("multipush: %v", err)
+	}
+	if len(res.Chunks) != 11 || res.PushId == 0 || res.Chunks[0].Endpoint != RESTAPI_PUSHACCOUNTLISTMULTIPLE {
+		t.Errorf("multipush chunks = %d, push_id %d", len(res.Chunks), res.PushId)
+	}
+	pushes := srv.Pushes()
+	if last := pushes[len(pushes)-1]; last.PushId != res.PushId || len(last.Targets) != ACCOUNT_LIST_MAX_TOTAL+1 {
+		t.Errorf("multipush targets = %d", len(last.Targets))
+	}
+
+	if _, err := c.PushToAccounts(ctx, []string{""}, msg); err == nil {
+		t.Errorf("empty account list accepted")
+	}
+}
+
+func TestPushToDevicesConcurrency(t *testing.T) {
+	transport := &concurrencyTransport{}
+	c, srv := newTestClient(t, WithTransport(transport), WithBatchConcurrency(2))
+	srv.RegisterToken(testTokenA)
+	msg := EasyMessageAndroid("title", "content")
+
+	res, err := c.PushToDevices(context.Background(), []string{testTokenA}, msg)
+	if err != nil || res.Chunks[0].Endpoint != RESTAPI_PUSHSINGLEDEVICE {
+		t.Fatalf("single device: %+v, %v", res, err)
+	}
+
+	tokens := makeTargets("token", 5500)
+	res, err = c.PushToDevices(context.Background(), tokens, msg)
+	if err != nil {
+		t.Fatalf("PushToDevices: %v", err)
+	}
+	if len(res.Chunks) != 6 || len(res.Chunks[5].Targets) != 500 {
+		t.Errorf("chunks = %d", len(res.Chunks))
+	}
+	if transport.max > 2 {
+		t.Errorf("max concurrent requests = %d, want <= 2", transport.max)
+	}
+
+	srv.FailNext("/v2/push/device_list_multiple", 15, "server busy")
+	res, err = c.PushToDevices(context.Background(), tokens, msg)
+	if res == nil {
+		t.Fatalf("partial failure: nil result, err %v", err)
+	}
+	if err == nil || res.Success() || len(res.FailedTargets()) != MULTIPLE_LIST_MAX_SIZE {
+		t.Errorf("partial failure: err %v, failed %d", err, len(res.FailedTargets()))
+	}
+	pushes := srv.Pushes()
+	if got := len(pushes[len(pushes)-1].Targets); got != 5500-MULTIPLE_LIST_MAX_SIZE {
+		t.Errorf("delivered targets = %d", got)
+	}
+}
+
+// CreateMultipush 不支持定时和循环推送，走这条路径时拒绝发送
+func TestPushToBatchRejectsTimingPush(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+	srv.RegisterToken(testTokenB)
+	ctx := context.Background()
+
+	msg := EasyMessageAndroid("title", "content")
+	msg.SetSendTime(time.Now().Add(time.Hour))
+	if _, err := c.PushToAccounts(ctx, makeTargets("user", 2), msg); err != nil {
+		t.Errorf("timing push to account list: %v", err)
+	}
+	if _, err := c.PushToDevices(ctx, []string{testTokenA}, msg); err != nil {
+		t.Errorf("timing push to single device: %v", err)
+	}
+
+	sent := len(srv.Pushes())
+	_, err := c.PushToAccounts(ctx, makeTargets("user", ACCOUNT_LIST_MAX_TOTAL+1), msg)
+	if got := ValidationFields(t, err); !reflect.DeepEqual(got, []string{"send_time"}) {
+		t.Errorf("timing multipush to accounts: fields = %v", got)
+	}
+	if _, err := c.PushToDevices(ctx, []string{testTokenA, testTokenB}, msg); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("timing multipush to devices: err = %v", err)
+	}
+
+	loop := EasyMessageAndroid("title", "content")
+	loop.LoopInterval, loop.LoopTimes = 1, 3
+	_, err = c.PushToDevices(ctx, []string{testTokenA, testTokenB}, loop)
+	if got := ValidationFields(t, err); !reflect.DeepEqual(got, []string{"loop_interval"}) {
+		t.Errorf("loop multipush to devices: fields = %v", got)
+	}
+	if got := len(srv.Pushes()); got != sent {
+		t.Errorf("rejected batches sent %d pushes", got-sent)
+	}
+}
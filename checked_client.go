@@ -0,0 +1,272 @@
+package xinge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Client 的 error 风格视图，与 Client 的高级接口一一对应，区别在于返回 (*XgResponse, error)：
+//   - 服务端返回 ret_code != 0 时，同时返回响应和 *APIError
+//   - 网络、读取、解析失败时返回 *TransportError，可用 errors.Unwrap 取得底层错误
+//   - 本地参数校验失败时返回 *ValidationError，满足 errors.Is(err, ErrInvalidParam)
+type CheckedClient struct {
+	c *Client
+}
+
+// 返回 Client 的 error 风格视图
+func (c *Client) Checked() *CheckedClient {
+	return &CheckedClient{c}
+}
+
+// 推送给指定设备
+func (cc *CheckedClient) PushSingleDevice(ctx context.Context, deviceToken string, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+
+	params := initParams()
+	params["device_token"] = deviceToken
+	return cc.c.doPush(ctx, RESTAPI_PUSHSINGLEDEVICE, message, params)
+}
+
+// 推送给指定账号
+func (cc *CheckedClient) PushSingleAccount(ctx context.Context, account string, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+
+	params := initParams()
+	params["account"] = account
+	return cc.c.doPush(ctx, RESTAPI_PUSHSINGLEACCOUNT, message, params)
+}
+
+// 推送给多个账号
+func (cc *CheckedClient) PushAccountList(ctx context.Context, accountList []string, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+
+	params := initParams()
+	accountListByt, err := json.Marshal(accountList)
+	if err != nil {
+		return nil, newValidationError("account_list", err.Error())
+	}
+	params["account_list"] = string(accountListByt)
+	return cc.c.doPush(ctx, RESTAPI_PUSHACCOUNTLIST, message, params)
+}
+
+// 推送给全量设备
+func (cc *CheckedClient) PushAllDevices(ctx context.Context, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+
+	params := initParams()
+	return cc.c.doPush(ctx, RESTAPI_PUSHALLDEVICE, message, params)
+}
+
+// 推送给多个tags对应的设备，tagOp 取值必须是 AND 或 OR
+func (cc *CheckedClient) PushTags(ctx context.Context, tagList []string, tagOp string, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+	if len(tagList) <= 0 {
+		return nil, newValidationError("tags_list", "empty tag list")
+	}
+	if tagOp != "AND" && tagOp != "OR" {
+		return nil, newValidationError("tags_op", "must be AND or OR")
+	}
+	for i, tag := range tagList {
+		if reason := checkTag(tag); reason != "" {
+			return nil, newValidationError(fmt.Sprintf("tags_list[%d]", i), reason)
+		}
+	}
+
+	params := initParams()
+	tagListByt, err := json.Marshal(tagList)
+	if err != nil {
+		return nil, newValidationError("tags_list", err.Error())
+	}
+	params["tags_list"] = string(tagListByt)
+	params["tags_op"] = tagOp
+
+	if message.GetLoopInterval() > 0 && message.GetLoopTimes() > 0 {
+		params["loop_interval"] = message.GetLoopInterval()
+		params["loop_times"] = message.GetLoopTimes()
+	}
+
+	return cc.c.doPush(ctx, RESTAPI_PUSHTAGS, message, params)
+}
+
+// 创建大批量推送消息，push_id 在 XgResult.PushId 中返回
+func (cc *CheckedClient) CreateMultipush(ctx context.Context, message Message) (*XgResponse, error) {
+	if message == nil {
+		return nil, newValidationError("message", "message nil")
+	}
+
+	params := initParams()
+	return cc.c.doPush(ctx, RESTAPI_CREATEMULTIPUSH, message, params)
+}
+
+// 推送消息给大批量账号，accountList 数量最多为1000个
+func (cc *CheckedClient) PushAccountListMultiple(ctx context.Context, pushId int64, accountList []string) (*XgResponse, error) {
+	if pushId <= 0 {
+		return nil, newValidationError("push_id", "must be positive")
+	}
+	if len(accountList) <= 0 {
+		return nil, newValidationError("account_list", "empty account list")
+	}
+
+	params := initParams()
+	params["push_id"] = pushId
+	accountListByt, err := json.Marshal(accountList)
+	if err != nil {
+		return nil, newValidationError("account_list", err.Error())
+	}
+	params["account_list"] = string(accountListByt)
+
+	return cc.c.do(ctx, RESTAPI_PUSHACCOUNTLISTMULTIPLE, params)
+}
+
+// 推送消息给大批量设备，deviceList 数量最多为1000个
+func (cc *CheckedClient) PushDeviceListMultiple(ctx context.Context, pushId int64, deviceList []string) (*XgResponse, error) {
+	if pushId <= 0 {
+		return nil, newValidationError("push_id", "must be positive")
+	}
+	if len(deviceList) <= 0 {
+		return nil, newValidationError("device_list", "empty device list")
+	}
+
+	params := initParams()
+	params["push_id"] = pushId
+	deviceListByt, err := json.Marshal(deviceList)
+	if err != nil {
+		return nil, newValidationError("device_list", err.Error())
+	}
+	params["device_list"] = string(deviceListByt)
+
+	return cc.c.do(ctx, RESTAPI_PUSHDEVICELISTMULTIPLE, params)
+}
+
+// 查询群发消息的状态，可同时查询多个pushId状态
+func (cc *CheckedClient) QueryPushStatus(ctx context.Context, pushIdList []string) (*XgResponse, error) {
+	if len(pushIdList) == 0 {
+		return nil, newValidationError("push_ids", "empty push id list")
+	}
+
+	ids := make([]pushIdEntry, len(pushIdList))
+	for i, pushId := range pushIdList {
+		if !isPushId(pushId) {
+			return nil, newValidationError(fmt.Sprintf("push_ids[%d]", i), "must be a decimal push id, got "+strconv.Quote(pushId))
+		}
+		ids[i] = pushIdEntry{PushId: pushId}
+	}
+	idsByt, err := json.Marshal(ids)
+	if err != nil {
+		return nil, newValidationError("push_ids", err.Error())
+	}
+
+	params := initParams()
+	params["push_ids"] = string(idsByt)
+
+	return cc.c.do(ctx, RESTAPI_QUERYPUSHSTATUS, params)
+}
+
+// 查询应用覆盖的设备数
+func (cc *CheckedClient) QueryDeviceCount(ctx context.Context) (*XgResponse, error) {
+	params := initParams()
+	return cc.c.do(ctx, RESTAPI_QUERYDEVICECOUNT, params)
+}
+
+// 查询应用当前所有的tags，从 start 开始最多取 limit 个
+func (cc *CheckedClient) QueryTags(ctx context.Context, start, limit int64) (*XgResponse, error) {
+	params := initParams()
+	params["start"] = start
+	params["limit"] = limit
+	return cc.c.do(ctx, RESTAPI_QUERYTAGS, params)
+}
+
+// 查询应用所有的tags，如果超过100个，取前100个
+func (cc *CheckedClient) QueryTagsBefore100(ctx context.Context) (*XgResponse, error) {
+	return cc.QueryTags(ctx, 0, 100)
+}
+
+// 查询带有指定tag的设备数量
+func (cc *CheckedClient) QueryTagTokenNum(ctx context.Context, tag string) (*XgResponse, error) {
+	if err := ValidateTag(tag); err != nil {
+		return nil, err
+	}
+
+	params := initParams()
+	params["tag"] = tag
+	return cc.c.do(ctx, RESTAPI_QUERYTAGTOKENNUM, params)
+}
+
+// 查询设备下所有的tag
+func (cc *CheckedClient) QueryTokenTags(ctx context.Context, deviceToken string) (*XgResponse, error) {
+	params := initParams()
+	params["device_token"] = deviceToken
+	return cc.c.do(ctx, RESTAPI_QUERYTOKENTAGS, params)
+}
+
+// 取消尚未推送的定时任务
+func (cc *CheckedClient) CancelTimingPush(ctx context.Context, pushId string) (*XgResponse, error) {
+	params := initParams()
+	params["push_id"] = pushId
+	return cc.c.do(ctx, RESTAPI_CANCELTIMINGPUSH, params)
+}
+
+// 批量为token设备标签，每次调用最多输入20个pair
+func (cc *CheckedClient) BatchSetTag(ctx context.Context, tagTokenPairs []TagTokenPair) (*XgResponse, error) {
+	tagTokenList, err := cc.c.encodeTagTokenPairs(tagTokenPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	params := initParams()
+	params["tag_token_list"] = tagTokenList
+	return cc.c.do(ctx, RESTAPI_BATCHSETTAG, params)
+}
+
+// 批量为token删除标签，每次调用最多输入20个pair
+func (cc *CheckedClient) BatchDelTag(ctx context.Context, tagTokenPairs []TagTokenPair) (*XgResponse, error) {
+	tagTokenList, err := cc.c.encodeTagTokenPairs(tagTokenPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	params := initParams()
+	params["tag_token_list"] = tagTokenList
+	return cc.c.do(ctx, RESTAPI_BATCHDELTAG, params)
+}
+
+// 查询token相关的信息，包括最近一次活跃时间，离线消息数等
+func (cc *CheckedClient) QueryInfoOfToken(ctx context.Context, deviceToken string) (*XgResponse, error) {
+	params := initParams()
+	params["device_token"] = deviceToken
+	return cc.c.do(ctx, RESTAPI_QUERYINFOOFTOKEN, params)
+}
+
+// 查询账号绑定的token
+func (cc *CheckedClient) QueryTokensOfAccount(ctx context.Context, account string) (*XgResponse, error) {
+	params := initParams()
+	params["account"] = account
+	return cc.c.do(ctx, RESTAPI_QUERYTOKENSOFACCOUNT, params)
+}
+
+// 删除指定账号和token的绑定关系（token仍然有效）
+func (cc *CheckedClient) DeleteTokenOfAccount(ctx context.Context, account, deviceToken string) (*XgResponse, error) {
+	params := initParams()
+	params["account"] = account
+	params["device_token"] = deviceToken
+	return cc.c.do(ctx, RESTAPI_DELETETOKENOFACCOUNT, params)
+}
+
+// 删除指定账号绑定的所有token（token仍然有效）
+func (cc *CheckedClient) DeleteAllTokensOfAccount(ctx context.Context, account string) (*XgResponse, error) {
+	params := initParams()
+	params["account"] = account
+	return cc.c.do(ctx, RESTAPI_DELETEALLTOKENSOFACCOUNT, params)
+}
@@ -1,20 +1,28 @@
 package xinge
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
 const (
-	TYPE_ACTIVITY = 1
-	TYPE_URL      = 2
-	TYPE_INTENT   = 3
+	TYPE_ACTIVITY ActionType = 1
+	TYPE_URL      ActionType = 2
+	TYPE_INTENT   ActionType = 3
 )
 
 type ClickAction struct {
-	ActionType                  int      `json:"action_type,omitempty"`
-	Browser                     *Browser `json:"browser,omitempty"`
-	Activity                    string   `json:"activity,omitempty"`
-	Intent                      string   `json:"intent,omitempty"`
-	AtyAttr                     *AtyAttr `json:"aty_attr,omitempty"`
-	PackageName                 string   `json:"package_name,omitempty"`
-	PackageDownloadUrl          string   `json:"-"`
-	ConfirmOnPackageDownloadUrl int      `json:"-"`
+	ActionType                  ActionType `json:"action_type,omitempty"`
+	Browser                     *Browser   `json:"browser,omitempty"`
+	Activity                    string     `json:"activity,omitempty"`
+	Intent                      string     `json:"intent,omitempty"`
+	AtyAttr                     *AtyAttr   `json:"aty_attr,omitempty"`
+	PackageName                 string     `json:"package_name,omitempty"`
+	PackageDownloadUrl          string     `json:"-"`
+	ConfirmOnPackageDownloadUrl bool       `json:"-"`
 }
 
 func NewClickAction() *ClickAction {
@@ -25,14 +33,30 @@ func NewClickAction() *ClickAction {
 		AtyAttr:                     NewAtyAttr(),
 		PackageName:                 "",
 		PackageDownloadUrl:          "",
-		ConfirmOnPackageDownloadUrl: 1,
+		ConfirmOnPackageDownloadUrl: true,
 	}
 }
 
 func NewSimplekAction(packageName, activity string) *ClickAction {
 	action := NewClickAction()
 	action.Activity = activity
-	action.PackageName = ""
+	action.PackageName = packageName
+	return action
+}
+
+// 点击后打开网页，confirm 为 true 时先弹窗确认
+func NewUrlAction(pageUrl string, confirm bool) *ClickAction {
+	action := NewClickAction()
+	action.ActionType = TYPE_URL
+	action.Browser = &Browser{Url: pageUrl, ConfirmOnUrl: confirm}
+	return action
+}
+
+// 点击后按 intent 打开页面，intent 可以是 intent:// 或自定义 scheme 的 URI，可用 DeepLink 生成
+func NewIntentAction(intent string) *ClickAction {
+	action := NewClickAction()
+	action.ActionType = TYPE_INTENT
+	action.Intent = intent
 	return action
 }
 
@@ -48,7 +72,7 @@ func (s *ClickAction) SetIntent(intent string) {
 	s.Intent = intent
 }
 
-func (s *ClickAction) SetActionType(actionType int) {
+func (s *ClickAction) SetActionType(actionType ActionType) {
 	s.ActionType = actionType
 }
 
@@ -56,7 +80,7 @@ func (s *ClickAction) SetPackageDownloadUrl(packageDownloadUrl string) {
 	s.PackageDownloadUrl = packageDownloadUrl
 }
 
-func (s *ClickAction) SetConfirmOnPackageDownloadUrl(confirmOnPackageDownloadUrl int) {
+func (s *ClickAction) SetConfirmOnPackageDownloadUrl(confirmOnPackageDownloadUrl bool) {
 	s.ConfirmOnPackageDownloadUrl = confirmOnPackageDownloadUrl
 }
 
@@ -69,28 +93,55 @@ func (s *ClickAction) SetAtyAttr(atyAttr *AtyAttr) {
 }
 
 func (s *ClickAction) IsValid() bool {
+	return s.Validate() == nil
+}
+
+// 校验动作类型，以及 url、intent 动作所需的地址
+func (s *ClickAction) Validate() error {
+	var errs ValidationErrors
 	if s.ActionType < TYPE_ACTIVITY || s.ActionType > TYPE_INTENT {
-		return false
+		errs.add("action_type", fmt.Sprintf("must be between %d and %d, got %d", TYPE_ACTIVITY, TYPE_INTENT, s.ActionType))
 	}
 
 	if s.ActionType == TYPE_URL {
-		if s.Browser.Url == "" || s.Browser.ConfirmOnUrl < 0 || s.Browser.ConfirmOnUrl > 1 {
-			return false
+		if s.Browser == nil {
+			errs.add("browser", "required when action_type is url")
+		} else {
+			if s.Browser.Url == "" {
+				errs.add("browser.url", "required when action_type is url")
+			} else if !isHttpUrl(s.Browser.Url) {
+				errs.add("browser.url", "must be an http or https URL")
+			}
 		}
-		return true
 	}
 
 	if s.ActionType == TYPE_INTENT {
 		if s.Intent == "" {
-			return false
+			errs.add("intent", "required when action_type is intent")
+		} else if reason := checkIntentUri(s.Intent); reason != "" {
+			errs.add("intent", reason)
 		}
-		return true
 	}
 
-	return true
+	if s.ActionType == TYPE_ACTIVITY && s.Activity != "" && !activityPattern.MatchString(s.Activity) {
+		errs.add("activity", "must be a class name such as com.example.MainActivity or .MainActivity")
+	}
+
+	if s.PackageName != "" && !packageNamePattern.MatchString(s.PackageName) {
+		errs.add("package_name", "must be a package name such as com.example.app")
+	}
+
+	return errs.err()
 }
 
+// 打开网页的参数，序列化时 ConfirmOnUrl 输出为 0 或 1
 type Browser struct {
+	Url          string
+	ConfirmOnUrl bool
+}
+
+// Browser 在消息 JSON 中的结构
+type browserJSON struct {
 	Url          string `json:"url,omitempty"`
 	ConfirmOnUrl int    `json:"confirm,omitempty"`
 }
@@ -98,7 +149,7 @@ type Browser struct {
 func NewBrowser() *Browser {
 	return &Browser{
 		Url:          "",
-		ConfirmOnUrl: 0,
+		ConfirmOnUrl: false,
 	}
 }
 
@@ -106,10 +157,29 @@ func (s *Browser) SetUrl(url string) {
 	s.Url = url
 }
 
-func (s *Browser) SetConfirmOnUrl(confirmOnUrl int) {
+func (s *Browser) SetConfirmOnUrl(confirmOnUrl bool) {
 	s.ConfirmOnUrl = confirmOnUrl
 }
 
+func (s *Browser) MarshalJSON() ([]byte, error) {
+	return json.Marshal(browserJSON{Url: s.Url, ConfirmOnUrl: boolInt(s.ConfirmOnUrl)})
+}
+
+func (s *Browser) UnmarshalJSON(data []byte) error {
+	var w browserJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	var errs ValidationErrors
+	checkSwitch(&errs, "confirm", w.ConfirmOnUrl)
+	if err := errs.err(); err != nil {
+		return err
+	}
+	s.Url = w.Url
+	s.ConfirmOnUrl = w.ConfirmOnUrl == 1
+	return nil
+}
+
 type AtyAttr struct {
 	AtyAttrIntentFlag        int `json:"if,omitempty"`
 	AtyAttrPendingIntentFlag int `json:"pf,omitempty"`
@@ -126,6 +196,47 @@ func (s *AtyAttr) SetAtyAttrIntentFlag(atyAttrIntentFlag int) {
 	s.AtyAttrIntentFlag = atyAttrIntentFlag
 }
 
-func (s *AtyAttr) SetConfirmOnUrl(atyAttrPendingIntentFlag int) {
+func (s *AtyAttr) SetAtyAttrPendingIntentFlag(atyAttrPendingIntentFlag int) {
 	s.AtyAttrPendingIntentFlag = atyAttrPendingIntentFlag
 }
+
+// Deprecated: AtyAttr 没有确认参数，此方法设置的是 PendingIntent flag，请使用 SetAtyAttrPendingIntentFlag
+func (s *AtyAttr) SetConfirmOnUrl(atyAttrPendingIntentFlag int) {
+	s.SetAtyAttrPendingIntentFlag(atyAttrPendingIntentFlag)
+}
+
+var (
+	// Android 包名：至少两段，每段以字母开头
+	packageNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+	// Activity 类名：完整类名或以 . 开头的相对类名
+	activityPattern = regexp.MustCompile(`^(\.?[A-Za-z_$][A-Za-z0-9_$]*)(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+	// URI scheme，RFC 3986
+	schemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+)
+
+func isHttpUrl(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// 校验 intent URI，不合法时返回原因
+func checkIntentUri(intent string) string {
+	u, err := url.Parse(intent)
+	if err != nil || u.Scheme == "" {
+		return "must be an intent:// or custom scheme URI"
+	}
+	if u.Scheme != "intent" {
+		return ""
+	}
+
+	// intent://host/path#Intent;scheme=xxx;package=xxx;end
+	if !strings.HasPrefix(u.Fragment, "Intent;") || !strings.HasSuffix(u.Fragment, ";end") {
+		return "intent:// URI must end with #Intent;...;end"
+	}
+	for _, part := range strings.Split(u.Fragment, ";") {
+		if pkg := strings.TrimPrefix(part, "package="); pkg != part && !packageNamePattern.MatchString(pkg) {
+			return "intent:// URI has an invalid package name"
+		}
+	}
+	return ""
+}
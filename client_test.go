@@ -0,0 +1,209 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/panjunjie/xinge"
+	"github.com/panjunjie/xinge/xingetest"
+)
+
+const (
+	testAccessId    int64  = 2100000001
+	testSecretKey   string = "test-secret-key"
+	testTokenA      string = "0123456789012345678901234567890123456789"
+	testTokenB      string = "abcdefabcdefabcdefabcdefabcdefabcdefabcd"
+	testAccountName string = "user-100028"
+)
+
+func newTestClient(t *testing.T, opts ...Option) (*Client, *xingetest.Server) {
+	srv := xingetest.NewServer(testAccessId, testSecretKey)
+	t.Cleanup(srv.Close)
+	return NewClient(testAccessId, testSecretKey, append([]Option{WithBaseURL(srv.URL)}, opts...)...), srv
+}
+
+func TestClientAgainstFakeServer(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.BindAccount(testAccountName, testTokenA)
+	srv.RegisterToken(testTokenB)
+	msg := EasyMessageAndroid("title", "content")
+
+	if resp := c.PushSingleDevice(testTokenA, msg); resp.Code != 0 || resp.XgResult == nil || resp.XgResult.PushId <= 0 {
+		t.Fatalf("PushSingleDevice = %+v", resp)
+	}
+	if resp := c.PushSingleAccount(testAccountName, msg); resp.Code != 0 {
+		t.Errorf("PushSingleAccount = %+v", resp)
+	}
+	if resp := c.PushSingleAccount("nobody", msg); resp.Code != int(RETCODE_ACCOUNT_NOT_BOUND) {
+		t.Errorf("PushSingleAccount(nobody) = %+v, want ret_code 48", resp)
+	}
+
+	if resp := c.BatchSetTag([]TagTokenPair{{"vip", testTokenA}, {"vip", testTokenB}, {"beta", testTokenB}}); resp.Code != 0 {
+		t.Fatalf("BatchSetTag = %+v", resp)
+	}
+	if resp := c.QueryTokenTags(testTokenB); resp.XgResult == nil || len(resp.XgResult.Tags) != 2 {
+		t.Errorf("QueryTokenTags = %+v", resp)
+	}
+	if resp := c.QueryTags(0, 10); resp.XgResult == nil || resp.XgResult.Total != 2 {
+		t.Errorf("QueryTags = %+v", resp)
+	}
+	if resp := c.PushTags([]string{"vip", "beta"}, "AND", msg); resp.Code != 0 {
+		t.Errorf("PushTags = %+v", resp)
+	}
+	if resp := c.BatchDelTag([]TagTokenPair{{"beta", testTokenB}}); resp.Code != 0 {
+		t.Errorf("BatchDelTag = %+v", resp)
+	}
+	if tags := srv.Tags(); len(tags) != 1 || tags[0] != "vip" {
+		t.Errorf("server tags = %v, want [vip]", tags)
+	}
+
+	pushId := c.CreateMultipush(msg)
+	if pushId <= 0 {
+		t.Fatalf("CreateMultipush = %d", pushId)
+	}
+	if resp := c.PushDeviceListMultiple(pushId, []string{testTokenA, testTokenB}); resp.Code != 0 {
+		t.Errorf("PushDeviceListMultiple = %+v", resp)
+	}
+	if resp := c.QueryDeviceCount(); resp.XgResult == nil || resp.XgResult.DeviceNum != 2 {
+		t.Errorf("QueryDeviceCount = %+v", resp)
+	}
+	// testTokenA：单设备、单账号和大批量推送；testTokenB：标签和大批量推送
+	if resp := c.QueryInfoOfToken(testTokenA); resp.XgResult == nil || resp.XgResult.MsgsNum != 3 {
+		t.Errorf("QueryInfoOfToken(testTokenA) = %+v, want msgsNum 3", resp.XgResult)
+	}
+	if resp := c.QueryInfoOfToken(testTokenB); resp.XgResult == nil || resp.XgResult.MsgsNum != 2 {
+		t.Errorf("QueryInfoOfToken(testTokenB) = %+v, want msgsNum 2", resp.XgResult)
+	}
+
+	pushes := srv.Pushes()
+	if len(pushes) != 4 {
+		t.Fatalf("server received %d pushes, want 4", len(pushes))
+	}
+	if pushes[0].Message != msg.ToJSON() || pushes[0].Targets[0] != testTokenA {
+		t.Errorf("first push = %+v", pushes[0])
+	}
+}
+
+// XxxContext 在请求进行中被取消或超时时立即返回，错误信息来自 ctx
+func TestContextCancelsInFlightRequest(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+	msg := EasyMessageAndroid("title", "content")
+
+	srv.HangNext("/v2/push/single_device")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	resp := c.PushSingleDeviceContext(ctx, testTokenA, msg)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("PushSingleDeviceContext returned after %s, want prompt return on cancel", elapsed)
+	}
+	if resp.Code != -1 || !strings.Contains(resp.Msg, context.Canceled.Error()) {
+		t.Errorf("PushSingleDeviceContext = %+v, want ctx error", resp)
+	}
+
+	srv.HangNext("/v2/application/get_app_device_num")
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if resp := c.QueryDeviceCountContext(ctx); resp.Code != -1 || !strings.Contains(resp.Msg, context.DeadlineExceeded.Error()) {
+		t.Errorf("QueryDeviceCountContext = %+v, want ctx error", resp)
+	}
+
+	// 被取消的请求没有记录推送，之后的请求照常处理
+	if resp := c.PushSingleDevice(testTokenA, msg); resp.Code != 0 || len(srv.Pushes()) != 1 {
+		t.Errorf("PushSingleDevice after cancel = %+v, pushes %d", resp, len(srv.Pushes()))
+	}
+}
+
+func TestFakeServerRejectsBadSignature(t *testing.T) {
+	srv := xingetest.NewServer(testAccessId, testSecretKey)
+	defer srv.Close()
+
+	c := NewClient(testAccessId, "wrong-secret", WithBaseURL(srv.URL))
+	_, err := c.Checked().QueryDeviceCount(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.RetCode().IsAuthError() {
+		t.Errorf("err = %v, want sign error", err)
+	}
+}
+
+func TestFakeServerFailureInjection(t *testing.T) {
+	c, srv := newTestClient(t, WithRetryPolicy(&RetryPolicy{MaxAttempts: 3}))
+	srv.FailNext("/v2/application/get_app_device_num", int(RETCODE_SERVER_BUSY), "busy")
+	srv.DropNext("/v2/application/get_app_device_num")
+
+	if _, err := c.Checked().QueryDeviceCount(context.Background()); err != nil {
+		t.Errorf("QueryDeviceCount after injected failures: %v", err)
+	}
+
+	srv.FailNext("/v2/push/all_device", int(RETCODE_SERVER_BUSY), "busy")
+	resp := c.PushAllDevices(EasyMessageAndroid("t", "c"))
+	if !resp.Retryable() {
+		t.Errorf("PushAllDevices = %+v, want server busy", resp)
+	}
+}
+
+func TestFormEncodingRoundTrip(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+
+	payloads := []string{
+		"a&b=c",
+		"100% 优惠 + 满减",
+		"https://example.com/path?x=1&y=%E4%B8%AD&z=a+b#frag",
+		"semi;colon;separated",
+		`quote " backslash \ slash /`,
+		"多行\n文本\r\n\ttab",
+		"emoji 🎉🎉 & 全角＆符号＝",
+		"",
+	}
+	for i, payload := range payloads {
+		msg := EasyMessageAndroid(payload, payload)
+		msg.SetCustom(map[string]interface{}{"url": payload, "key=&+%": payload})
+
+		if _, err := c.Checked().PushSingleDevice(context.Background(), testTokenA, msg); err != nil {
+			t.Fatalf("payload %q: %v", payload, err)
+		}
+		pushes := srv.Pushes()
+		if got, want := pushes[i].Message, msg.ToJSON(); got != want {
+			t.Errorf("payload %q: server received\n%s\nwant\n%s", payload, got, want)
+		}
+	}
+}
+
+func TestScheduledPushAndCancel(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+
+	sendTime := time.Now().Add(time.Hour)
+	msg := EasyMessageAndroid("title", "scheduled")
+	msg.SetSendTime(sendTime)
+	msg.SetExpireTime(2 * 24 * 60 * 60)
+
+	resp := c.PushSingleDevice(testTokenA, msg)
+	if resp.Code != 0 || resp.XgResult == nil {
+		t.Fatalf("PushSingleDevice = %+v", resp)
+	}
+	push := srv.Pushes()[0]
+	if push.SendTime != sendTime.Format(DATETIMEFORMAT) || push.ExpireTime != 2*24*60*60 {
+		t.Errorf("server received send_time=%q expire_time=%d", push.SendTime, push.ExpireTime)
+	}
+	if push.Status != STATUS_PENDING {
+		t.Errorf("push status = %d, want pending", push.Status)
+	}
+
+	pushId := strconv.FormatInt(resp.XgResult.PushId, 10)
+	if resp := c.CancelTimingPush(pushId); resp.Code != 0 {
+		t.Fatalf("CancelTimingPush = %+v", resp)
+	}
+	if !srv.Pushes()[0].Canceled {
+		t.Errorf("push was not canceled on the server")
+	}
+	if resp := c.CancelTimingPush(pushId); resp.Code == 0 {
+		t.Errorf("second CancelTimingPush succeeded, want error")
+	}
+}
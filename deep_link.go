@@ -0,0 +1,127 @@
+package xinge
+
+import (
+	"net/url"
+	"strings"
+)
+
+/**
+ * 深度链接，用于生成 TYPE_INTENT 点击动作的 intent
+ *
+ * 自定义 scheme：myapp://host/path?k=v
+ * intent://：intent://host/path?k=v#Intent;scheme=myapp;package=com.example.app;end
+ */
+type DeepLink struct {
+	Scheme      string
+	Host        string
+	Path        string
+	Query       url.Values
+	PackageName string // 只用于 intent://，指定打开链接的应用
+	FallbackUrl string // 只用于 intent://，应用未安装时打开的网页
+}
+
+func NewDeepLink(scheme, host, path string) *DeepLink {
+	return &DeepLink{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path,
+		Query:  url.Values{},
+	}
+}
+
+func (s *DeepLink) AddQuery(key, value string) {
+	if s.Query == nil {
+		s.Query = url.Values{}
+	}
+	s.Query.Add(key, value)
+}
+
+func (s *DeepLink) SetPackageName(packageName string) {
+	s.PackageName = packageName
+}
+
+func (s *DeepLink) SetFallbackUrl(fallbackUrl string) {
+	s.FallbackUrl = fallbackUrl
+}
+
+// 校验 scheme 为 App 自定义的 scheme、host 非空，以及 path、包名和备用网页的格式
+func (s *DeepLink) Validate() error {
+	var errs ValidationErrors
+	if !schemePattern.MatchString(s.Scheme) {
+		errs.add("scheme", "must start with a letter and contain only letters, digits, +, - or .")
+	} else if s.Scheme == "intent" || s.Scheme == "http" || s.Scheme == "https" {
+		errs.add("scheme", "must be the app's custom scheme, got "+s.Scheme)
+	}
+
+	if s.Host == "" {
+		errs.add("host", "required")
+	} else if strings.ContainsAny(s.Host, "/?# ") {
+		errs.add("host", "must not contain /, ?, # or spaces")
+	}
+
+	if s.Path != "" && !strings.HasPrefix(s.Path, "/") {
+		errs.add("path", "must start with /")
+	}
+
+	if s.PackageName != "" && !packageNamePattern.MatchString(s.PackageName) {
+		errs.add("package_name", "must be a package name such as com.example.app")
+	}
+
+	if s.FallbackUrl != "" && !isHttpUrl(s.FallbackUrl) {
+		errs.add("fallback_url", "must be an http or https URL")
+	}
+	return errs.err()
+}
+
+// 自定义 scheme 的 URI，如 myapp://host/path?k=v
+func (s *DeepLink) URI() string {
+	return s.url(s.Scheme).String()
+}
+
+// intent:// 形式的 URI，scheme、包名和备用网页放在 #Intent;...;end 中
+func (s *DeepLink) IntentURI() string {
+	extras := []string{"Intent", "scheme=" + s.Scheme}
+	if s.PackageName != "" {
+		extras = append(extras, "package="+s.PackageName)
+	}
+	if s.FallbackUrl != "" {
+		extras = append(extras, "S.browser_fallback_url="+url.QueryEscape(s.FallbackUrl))
+	}
+	extras = append(extras, "end")
+	return s.url("intent").String() + "#" + strings.Join(extras, ";")
+}
+
+func (s *DeepLink) url(scheme string) *url.URL {
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     s.Host,
+		Path:     s.Path,
+		RawQuery: s.Query.Encode(),
+	}
+}
+
+/**
+ * 生成打开自定义 scheme URI 的点击动作
+ *
+ * @return 点击动作，深度链接不合法时返回 ValidationErrors
+ */
+func (s *DeepLink) SchemeAction() (*ClickAction, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return NewIntentAction(s.URI()), nil
+}
+
+/**
+ * 生成打开 intent:// URI 的点击动作，设置了 PackageName 时同时写入点击动作的 package_name
+ *
+ * @return 点击动作，深度链接不合法时返回 ValidationErrors
+ */
+func (s *DeepLink) IntentAction() (*ClickAction, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	action := NewIntentAction(s.IntentURI())
+	action.PackageName = s.PackageName
+	return action, nil
+}
@@ -0,0 +1,47 @@
+package xinge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepLink(t *testing.T) {
+	link := NewDeepLink("myapp", "orders", "/detail")
+	link.AddQuery("id", "42")
+	link.AddQuery("from", "push & mail")
+	link.SetPackageName("com.example.shop")
+	link.SetFallbackUrl("https://example.com/orders/42")
+
+	if got, want := link.URI(), "myapp://orders/detail?from=push+%26+mail&id=42"; got != want {
+		t.Errorf("URI = %q, want %q", got, want)
+	}
+	want := "intent://orders/detail?from=push+%26+mail&id=42#Intent;scheme=myapp;package=com.example.shop;S.browser_fallback_url=https%3A%2F%2Fexample.com%2Forders%2F42;end"
+	if got := link.IntentURI(); got != want {
+		t.Errorf("IntentURI = %q, want %q", got, want)
+	}
+
+	action, err := link.IntentAction()
+	if err != nil {
+		t.Fatalf("IntentAction: %v", err)
+	}
+	if action.ActionType != TYPE_INTENT || action.Intent != want || action.PackageName != "com.example.shop" {
+		t.Errorf("IntentAction = %+v", action)
+	}
+	if err := action.Validate(); err != nil {
+		t.Errorf("IntentAction().Validate: %v", err)
+	}
+
+	action, err = link.SchemeAction()
+	if err != nil || action.Intent != link.URI() || action.Validate() != nil {
+		t.Errorf("SchemeAction = %+v, %v", action, err)
+	}
+
+	bad := NewDeepLink("https", "a/b", "detail")
+	bad.SetPackageName("shop")
+	bad.SetFallbackUrl("javascript:alert(1)")
+	if _, err := bad.IntentAction(); err == nil {
+		t.Fatal("IntentAction accepted invalid deep link")
+	} else if got, want := validationFields(t, err), []string{"scheme", "host", "path", "package_name", "fallback_url"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package xinge
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// 同时持有 Android 和 iOS 两个应用的 Client，把一条 UniversalMessage 同时推送到两个平台
+type DualClient struct {
+	Android     *Client
+	IOS         *Client
+	Environment Environment // iOS 推送环境，IOSENV_PROD 或 IOSENV_DEV
+}
+
+// 实例化 DualClient，opts 同时作用于两个平台的 Client
+func NewDualClient(androidAccessId int64, androidSecretKey string, iosAccessId int64, iosSecretKey string, env Environment, opts ...Option) *DualClient {
+	return &DualClient{
+		Android:     NewClient(androidAccessId, androidSecretKey, opts...),
+		IOS:         NewClient(iosAccessId, iosSecretKey, opts...),
+		Environment: env,
+	}
+}
+
+// 两个平台各自的推送结果
+type DualResponse struct {
+	Android XgResponse
+	IOS     XgResponse
+}
+
+// 合并两个平台的推送结果，见 MergeResponses
+func (s *DualResponse) Merged() XgResponse {
+	return MergeResponses(s.Android, s.IOS)
+}
+
+// 两个平台都推送成功
+func (s *DualResponse) Success() bool {
+	return s.Android.Success() && s.IOS.Success()
+}
+
+// 合并两个 XgResponse：ret_code 取第一个失败的，err_msg 拼接在一起，
+// 结果中的列表合并、数量相加，PushId 取第一个非 0 的（两个平台的 push_id 请从 DualResponse 中分别获取）
+func MergeResponses(a, b XgResponse) XgResponse {
+	res := XgResponse{Code: a.Code}
+	if res.Code == 0 {
+		res.Code = b.Code
+	}
+
+	msgs := make([]string, 0, 2)
+	for _, msg := range []string{a.Msg, b.Msg} {
+		if msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	res.Msg = strings.Join(msgs, "; ")
+
+	if a.XgResult == nil && b.XgResult == nil {
+		return res
+	}
+
+	merged := &XgResult{}
+	for _, r := range []*XgResult{a.XgResult, b.XgResult} {
+		if r == nil {
+			continue
+		}
+		if merged.PushId == 0 {
+			merged.PushId = r.PushId
+		}
+		merged.Tokens = append(merged.Tokens, r.Tokens...)
+		merged.Tags = append(merged.Tags, r.Tags...)
+		merged.DeviceNum += r.DeviceNum
+		merged.MsgsNum += r.MsgsNum
+		merged.Total += r.Total
+		merged.XgResultList = append(merged.XgResultList, r.XgResultList...)
+	}
+	res.XgResult = merged
+	return res
+}
+
+// 并发地向两个平台推送
+func (c *DualClient) fanOut(message *UniversalMessage, android func(Message) XgResponse, ios func(Message) XgResponse) *DualResponse {
+	res := &DualResponse{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		res.Android = android(message.ToAndroid())
+	}()
+	go func() {
+		defer wg.Done()
+		res.IOS = ios(message.ToIOS(c.Environment))
+	}()
+	wg.Wait()
+	return res
+}
+
+/**
+ * 同时推送给两个平台的指定账号
+ *
+ * @param account 目标账号
+ * @param message 待推送的跨平台消息
+ * @return 两个平台各自的执行结果
+ */
+func (c *DualClient) PushSingleAccount(account string, message *UniversalMessage) *DualResponse {
+	return c.PushSingleAccountContext(context.Background(), account, message)
+}
+
+// PushSingleAccountContext 同 PushSingleAccount，两个平台的请求共用 ctx
+func (c *DualClient) PushSingleAccountContext(ctx context.Context, account string, message *UniversalMessage) *DualResponse {
+	return c.fanOut(message,
+		func(m Message) XgResponse { return c.Android.PushSingleAccountContext(ctx, account, m) },
+		func(m Message) XgResponse { return c.IOS.PushSingleAccountContext(ctx, account, m) })
+}
+
+/**
+ * 同时推送给两个平台的多个账号
+ *
+ * @param accountList 目标账号列表
+ * @param message 待推送的跨平台消息
+ * @return 两个平台各自的执行结果
+ */
+func (c *DualClient) PushAccountList(accountList []string, message *UniversalMessage) *DualResponse {
+	return c.PushAccountListContext(context.Background(), accountList, message)
+}
+
+// PushAccountListContext 同 PushAccountList
+func (c *DualClient) PushAccountListContext(ctx context.Context, accountList []string, message *UniversalMessage) *DualResponse {
+	return c.fanOut(message,
+		func(m Message) XgResponse { return c.Android.PushAccountListContext(ctx, accountList, m) },
+		func(m Message) XgResponse { return c.IOS.PushAccountListContext(ctx, accountList, m) })
+}
+
+/**
+ * 同时推送给两个平台的全量设备
+ *
+ * @param message 待推送的跨平台消息
+ * @return 两个平台各自的执行结果
+ */
+func (c *DualClient) PushAllDevices(message *UniversalMessage) *DualResponse {
+	return c.PushAllDevicesContext(context.Background(), message)
+}
+
+// PushAllDevicesContext 同 PushAllDevices
+func (c *DualClient) PushAllDevicesContext(ctx context.Context, message *UniversalMessage) *DualResponse {
+	return c.fanOut(message,
+		func(m Message) XgResponse { return c.Android.PushAllDevicesContext(ctx, m) },
+		func(m Message) XgResponse { return c.IOS.PushAllDevicesContext(ctx, m) })
+}
+
+/**
+ * 同时推送给两个平台中多个tags对应的设备
+ *
+ * @param tagList 指定推送的tag列表
+ * @param tagOp 多个tag的运算关系，取值必须是下面之一： AND OR
+ * @param message 待推送的跨平台消息
+ * @return 两个平台各自的执行结果
+ */
+func (c *DualClient) PushTags(tagList []string, tagOp string, message *UniversalMessage) *DualResponse {
+	return c.PushTagsContext(context.Background(), tagList, tagOp, message)
+}
+
+// PushTagsContext 同 PushTags
+func (c *DualClient) PushTagsContext(ctx context.Context, tagList []string, tagOp string, message *UniversalMessage) *DualResponse {
+	return c.fanOut(message,
+		func(m Message) XgResponse { return c.Android.PushTagsContext(ctx, tagList, tagOp, m) },
+		func(m Message) XgResponse { return c.IOS.PushTagsContext(ctx, tagList, tagOp, m) })
+}
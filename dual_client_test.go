@@ -0,0 +1,74 @@
+package xinge_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+	"github.com/panjunjie/xinge/xingetest"
+)
+
+func TestDualClientPushSingleAccount(t *testing.T) {
+	const iosAccessId int64 = 2200000001
+	androidSrv := xingetest.NewServer(testAccessId, testSecretKey)
+	defer androidSrv.Close()
+	iosSrv := xingetest.NewServer(iosAccessId, "ios-secret")
+	defer iosSrv.Close()
+	androidSrv.BindAccount(testAccountName, testTokenA)
+	iosSrv.BindAccount(testAccountName, "ios-token")
+
+	dual := &DualClient{
+		Android:     NewClient(testAccessId, testSecretKey, WithBaseURL(androidSrv.URL)),
+		IOS:         NewClient(iosAccessId, "ios-secret", WithBaseURL(iosSrv.URL)),
+		Environment: IOSENV_PROD,
+	}
+
+	msg := NewUniversalMessage("新消息", "你有一条新回复")
+	msg.Custom = map[string]interface{}{"topic": 42}
+	msg.Badge = 3
+	msg.Sound = "ding.caf"
+	msg.ClickURL = "https://example.com/topic/42"
+
+	res := dual.PushSingleAccount(testAccountName, msg)
+	if !res.Success() {
+		t.Fatalf("PushSingleAccount = %+v", res)
+	}
+	merged := res.Merged()
+	if merged.Code != 0 || merged.XgResult == nil || merged.XgResult.PushId != res.Android.XgResult.PushId {
+		t.Errorf("Merged = %+v", merged)
+	}
+
+	var android map[string]interface{}
+	json.Unmarshal([]byte(androidSrv.Pushes()[0].Message), &android)
+	if android["title"] != "新消息" || android["ring_raw"] != "ding" || android["badge_type"] != 3.0 || android["action"].(map[string]interface{})["browser"] == nil {
+		t.Errorf("android message = %v", android)
+	}
+
+	iosPush := iosSrv.Pushes()[0]
+	var ios struct {
+		Aps    map[string]interface{} `json:"aps"`
+		Custom map[string]interface{} `json:"custom"`
+	}
+	json.Unmarshal([]byte(iosPush.Message), &ios)
+	if ios.Aps["badge"] != 3.0 || ios.Aps["sound"] != "ding.caf" || ios.Custom["url"] != msg.ClickURL || ios.Custom["topic"] != 42.0 {
+		t.Errorf("ios message = %s", iosPush.Message)
+	}
+	if alert, _ := ios.Aps["alert"].(map[string]interface{}); alert["title"] != "新消息" || alert["body"] != "你有一条新回复" {
+		t.Errorf("ios alert = %v", ios.Aps["alert"])
+	}
+	if iosPush.Environment != int(IOSENV_PROD) {
+		t.Errorf("ios environment = %d", iosPush.Environment)
+	}
+	if _, ok := msg.Custom["url"]; ok {
+		t.Errorf("UniversalMessage.Custom was modified")
+	}
+}
+
+func TestMergeResponses(t *testing.T) {
+	a := XgResponse{Code: 0, XgResult: &XgResult{DeviceNum: 3, Tags: []string{"a"}}}
+	b := XgResponse{Code: 15, Msg: "busy", XgResult: &XgResult{DeviceNum: 4, Tags: []string{"b"}}}
+	m := MergeResponses(a, b)
+	if m.Code != 15 || m.Msg != "busy" || m.XgResult.DeviceNum != 7 || len(m.XgResult.Tags) != 2 {
+		t.Errorf("MergeResponses = %+v %+v", m, m.XgResult)
+	}
+}
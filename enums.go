@@ -0,0 +1,161 @@
+package xinge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// 消息类型：TYPE_NOTIFICATION、TYPE_MESSAGE（Android），TYPE_APNS_NOTIFICATION、TYPE_REMOTE_NOTIFICATION（iOS）
+type MessageType int
+
+// 点击通知后的动作：TYPE_ACTIVITY、TYPE_URL、TYPE_INTENT
+type ActionType int
+
+// iOS 推送环境：IOSENV_PROD、IOSENV_DEV，Android 消息为 0
+type Environment int
+
+// 设备类型：DEVICE_*
+type DeviceType int
+
+// 推送任务的状态：STATUS_PENDING、STATUS_PUSHING、STATUS_FINISHED、STATUS_FAILED
+type PushStatus int
+
+// 各枚举值的名称，用于 String()。枚举类型与旧版本一致序列化为数字，反序列化时接受数字或名称
+var messageTypeNames = map[int]string{
+	1:  "notification",
+	2:  "message",
+	11: "apns_notification",
+	12: "remote_notification",
+}
+
+var actionTypeNames = map[int]string{
+	1: "activity",
+	2: "url",
+	3: "intent",
+}
+
+var environmentNames = map[int]string{
+	1: "prod",
+	2: "dev",
+}
+
+var deviceTypeNames = map[int]string{
+	0: "all",
+	1: "browser",
+	2: "pc",
+	3: "android",
+	4: "ios",
+	5: "winphone",
+}
+
+var pushStatusNames = map[int]string{
+	0: "pending",
+	1: "pushing",
+	2: "finished",
+	3: "failed",
+}
+
+func (t MessageType) String() string {
+	return enumString("MessageType", messageTypeNames, int(t))
+}
+
+func (t MessageType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+func (t *MessageType) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum("MessageType", messageTypeNames, data)
+	*t = MessageType(v)
+	return err
+}
+
+func (t ActionType) String() string {
+	return enumString("ActionType", actionTypeNames, int(t))
+}
+
+func (t ActionType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+func (t *ActionType) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum("ActionType", actionTypeNames, data)
+	*t = ActionType(v)
+	return err
+}
+
+func (e Environment) String() string {
+	return enumString("Environment", environmentNames, int(e))
+}
+
+func (e Environment) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(e))), nil
+}
+
+func (e *Environment) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum("Environment", environmentNames, data)
+	*e = Environment(v)
+	return err
+}
+
+func (d DeviceType) String() string {
+	return enumString("DeviceType", deviceTypeNames, int(d))
+}
+
+func (d DeviceType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(d))), nil
+}
+
+func (d *DeviceType) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum("DeviceType", deviceTypeNames, data)
+	*d = DeviceType(v)
+	return err
+}
+
+func (s PushStatus) String() string {
+	return enumString("PushStatus", pushStatusNames, int(s))
+}
+
+// 与 QueryPushStatus 的响应一致，序列化为数字
+func (s PushStatus) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+func (s *PushStatus) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum("PushStatus", pushStatusNames, data)
+	*s = PushStatus(v)
+	return err
+}
+
+func enumString(typeName string, names map[int]string, v int) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", typeName, v)
+}
+
+func unmarshalEnum(typeName string, names map[int]string, data []byte) (int, error) {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		return n, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, fmt.Errorf("xinge: %s must be a number or a name, got %s", typeName, data)
+	}
+	for v, name := range names {
+		if name == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("xinge: unknown %s %q", typeName, s)
+}
+
+// 开关参数在消息中为 0 或 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package xinge_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+)
+
+func TestEnumString(t *testing.T) {
+	cases := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{TYPE_NOTIFICATION, "notification"},
+		{TYPE_REMOTE_NOTIFICATION, "remote_notification"},
+		{MessageType(7), "MessageType(7)"},
+		{TYPE_INTENT, "intent"},
+		{IOSENV_PROD, "prod"},
+		{DEVICE_IOS, "ios"},
+		{DeviceType(9), "DeviceType(9)"},
+		{STATUS_FINISHED, "finished"},
+		{PushStatus(5), "PushStatus(5)"},
+	}
+	for _, tc := range cases {
+		if got := tc.v.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestEnumJSON(t *testing.T) {
+	byt, err := json.Marshal(struct {
+		Type MessageType `json:"type"`
+		Env  Environment `json:"env"`
+	}{TYPE_APNS_NOTIFICATION, IOSENV_DEV})
+	if err != nil || string(byt) != `{"type":11,"env":2}` {
+		t.Errorf("Marshal = %s, %v", byt, err)
+	}
+
+	var v struct {
+		Action ActionType `json:"action"`
+		Device DeviceType `json:"device"`
+	}
+	if err := json.Unmarshal([]byte(`{"action":"url","device":3}`), &v); err != nil || v.Action != TYPE_URL || v.Device != DEVICE_ANDROID {
+		t.Errorf("Unmarshal = %+v, %v", v, err)
+	}
+	if err := json.Unmarshal([]byte(`{"action":"web"}`), &v); err == nil {
+		t.Errorf("Unmarshal accepted unknown action name")
+	}
+}
+
+// 类型化之后消息 JSON 和表单参数与旧版本保持一致
+func TestEnumWireFormat(t *testing.T) {
+	msg := EasyMessageAndroid("title", "content")
+	msg.SetAction(NewUrlAction("https://example.com", true))
+	for _, want := range []string{`"ring":0`, `"vibrate":1`, `"clearable":1`, `"lights":1`, `"action_type":2`, `"confirm":1`} {
+		if !strings.Contains(msg.ToJSON(), want) {
+			t.Errorf("ToJSON = %s, missing %s", msg.ToJSON(), want)
+		}
+	}
+
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+	if _, err := c.Checked().PushSingleDevice(context.Background(), testTokenA, msg); err != nil {
+		t.Fatal(err)
+	}
+	params := srv.Pushes()[0].Params
+	if params.Get("message_type") != "1" || params.Get("multi_pkg") != "0" || params.Get("environment") != "0" {
+		t.Errorf("params = %v", params)
+	}
+}
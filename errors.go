@@ -0,0 +1,127 @@
+package xinge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 所有 ValidationError 都满足 errors.Is(err, ErrInvalidParam)
+var ErrInvalidParam = errors.New("xinge: invalid parameter")
+
+// 信鸽服务端返回 ret_code != 0 时的错误，携带原始的 ret_code 和 err_msg
+type APIError struct {
+	Code int
+	Msg  string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("xinge: api error ret_code=%d err_msg=%s", e.Code, e.Msg)
+}
+
+// 请求信鸽接口过程中的网络、读取或解析错误，Err 为底层错误
+type TransportError struct {
+	Op  string
+	URL string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("xinge: %s %s: %v", e.Op, e.URL, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// 本地参数校验失败的错误，Field 为出错的参数名
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("xinge: invalid %s: %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidParam
+}
+
+func newValidationError(field, reason string) *ValidationError {
+	return &ValidationError{Field: field, Reason: reason}
+}
+
+// 多个字段校验失败时的错误列表，Field 为字段路径，如 style.ring、accept_time[1].end.hour。
+// errors.As 可取出其中第一个 *ValidationError，errors.Is(err, ErrInvalidParam) 同样成立
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Field + ": " + v.Reason
+	}
+	return "xinge: invalid " + strings.Join(msgs, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, v := range e {
+		errs[i] = v
+	}
+	return errs
+}
+
+func (e *ValidationErrors) add(field, reason string) {
+	*e = append(*e, newValidationError(field, reason))
+}
+
+// 把子结构体 Validate 返回的错误加上 prefix 前缀后并入列表
+func (e *ValidationErrors) addNested(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	var list ValidationErrors
+	var single *ValidationError
+	if errors.As(err, &list) {
+		for _, v := range list {
+			e.add(joinField(prefix, v.Field), v.Reason)
+		}
+	} else if errors.As(err, &single) {
+		e.add(joinField(prefix, single.Field), single.Reason)
+	} else {
+		e.add(prefix, err.Error())
+	}
+}
+
+// 没有错误时返回 nil，避免返回非 nil 的空列表
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func joinField(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	if field == "" {
+		return prefix
+	}
+	return prefix + "." + field
+}
+
+// 把 (*XgResponse, error) 折叠成旧接口使用的 XgResponse：服务端错误原样返回，本地错误转为 Code=-1
+func toXgResponse(res *XgResponse, err error) XgResponse {
+	if err == nil {
+		return *res
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && res != nil {
+		return *res
+	}
+	return NewRespone(-1, err.Error())
+}
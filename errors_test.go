@@ -0,0 +1,45 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckedClientErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ret_code":-3,"err_msg":"sign error"}`))
+	}))
+	defer srv.Close()
+	cc := NewClient(2100000000, "secret", WithBaseURL(srv.URL)).Checked()
+
+	res, err := cc.QueryDeviceCount(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != -3 || apiErr.Msg != "sign error" {
+		t.Fatalf("err = %v, want *APIError -3", err)
+	}
+	if res == nil || res.Code != -3 {
+		t.Errorf("res = %+v, want server response", res)
+	}
+
+	_, err = cc.PushTags(context.Background(), []string{"a"}, "XOR", EasyMessageAndroid("t", "c"))
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || valErr.Field != "tags_op" || !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("err = %v, want *ValidationError on tags_op", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cc.QueryDeviceCount(ctx)
+	var transErr *TransportError
+	if !errors.As(err, &transErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want *TransportError wrapping context.Canceled", err)
+	}
+
+	legacy := NewClient(2100000000, "secret", WithBaseURL(srv.URL)).QueryDeviceCount()
+	if legacy.Code != -3 || legacy.Msg != "sign error" {
+		t.Errorf("legacy = %+v, want server response", legacy)
+	}
+}
@@ -0,0 +1,7 @@
+package xinge
+
+// 供 xinge_test 包中的测试使用的内部函数。依赖 xingetest 的测试放在 xinge_test 包中，避免 xingetest 引用 xinge 时循环导入
+var (
+	TruncateMessage  = truncateMessage
+	ValidationFields = validationFields
+)
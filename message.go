@@ -2,25 +2,31 @@ package xinge
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
 const (
-	TYPE_NOTIFICATION        = 1
-	TYPE_MESSAGE             = 2
-	TYPE_APNS_NOTIFICATION   = 11
-	TYPE_REMOTE_NOTIFICATION = 12
-	DATETIMEFORMAT           = "2006-01-02 15:04:05"
+	TYPE_NOTIFICATION        MessageType = 1
+	TYPE_MESSAGE             MessageType = 2
+	TYPE_APNS_NOTIFICATION   MessageType = 11
+	TYPE_REMOTE_NOTIFICATION MessageType = 12
+	DATETIMEFORMAT                       = "2006-01-02 15:04:05"
+	// 新建消息默认的离线存储时间（秒），与旧版本固定发送的 expire_time 一致
+	DEFAULT_EXPIRE_TIME = 600
 )
 
 type Message interface {
 	IsValid() bool
+	Validate() error
 	ToJSON() string
-	GetType() int
-	GetMultiPkg() int
-	GetEnvironment() int
+	GetType() MessageType
+	GetMultiPkg() bool
+	GetEnvironment() Environment
 	GetLoopInterval() int
 	GetLoopTimes() int
+	GetExpireTime() int
+	GetSendTime() string
 }
 
 type MessageAndroid struct {
@@ -29,8 +35,8 @@ type MessageAndroid struct {
 	ExpireTime   int                    `json:"expire_time"`
 	SendTime     string                 `json:"send_time"`
 	AcceptTime   []TimeInterval         `json:"accept_time,omitempty"`
-	Type         int                    `json:"message_type"`
-	MultiPkg     int                    `json:"multi_pkg"`
+	Type         MessageType            `json:"message_type"`
+	MultiPkg     bool                   `json:"multi_pkg"`
 	Style        *Style                 `json:"style,omitempty"`
 	ClickAction  *ClickAction           `json:"action,omitempty"`
 	Custom       map[string]interface{} `json:"custom_content,omitempty"`
@@ -43,10 +49,11 @@ func NewMessageAndroid() *MessageAndroid {
 	return &MessageAndroid{
 		Title:        "",
 		Content:      "",
+		ExpireTime:   DEFAULT_EXPIRE_TIME,
 		SendTime:     time.Now().Format(DATETIMEFORMAT),
 		AcceptTime:   nil,
 		Type:         TYPE_NOTIFICATION,
-		MultiPkg:     0,
+		MultiPkg:     false,
 		Raw:          "",
 		LoopInterval: -1,
 		LoopTimes:    -1,
@@ -74,7 +81,7 @@ func (s *MessageAndroid) SetCustom(custom map[string]interface{}) {
 	s.Custom = custom
 }
 
-func (s *MessageAndroid) SetType(t int) {
+func (s *MessageAndroid) SetType(t MessageType) {
 	s.Type = t
 }
 
@@ -90,19 +97,29 @@ func (s *MessageAndroid) AddAcceptTime(acceptTime TimeInterval) {
 	s.AcceptTime = append(s.AcceptTime, acceptTime)
 }
 
-func (s *MessageAndroid) SetMultiPkg(multiPkg int) {
+func (s *MessageAndroid) SetMultiPkg(multiPkg bool) {
 	s.MultiPkg = multiPkg
 }
 
-func (s *MessageAndroid) GetType() int {
+// 设置离线消息的存储时间（秒），最长 3 天，0 表示使用信鸽默认值
+func (s *MessageAndroid) SetExpireTime(expireTime int) {
+	s.ExpireTime = expireTime
+}
+
+// 设置定时推送的时间，晚于当前时间时为定时推送，可用 CancelTimingPush 取消
+func (s *MessageAndroid) SetSendTime(sendTime time.Time) {
+	s.SendTime = sendTime.Format(DATETIMEFORMAT)
+}
+
+func (s *MessageAndroid) GetType() MessageType {
 	return s.Type
 }
 
-func (s *MessageAndroid) GetMultiPkg() int {
+func (s *MessageAndroid) GetMultiPkg() bool {
 	return s.MultiPkg
 }
 
-func (s *MessageAndroid) GetEnvironment() int {
+func (s *MessageAndroid) GetEnvironment() Environment {
 	return 0
 }
 
@@ -114,51 +131,71 @@ func (s *MessageAndroid) GetLoopTimes() int {
 	return s.LoopTimes
 }
 
+func (s *MessageAndroid) GetExpireTime() int {
+	return s.ExpireTime
+}
+
+func (s *MessageAndroid) GetSendTime() string {
+	return s.SendTime
+}
+
 func (s *MessageAndroid) IsValid() bool {
-	if s.Raw == "" {
-		return true
-	}
+	return s.Validate() == nil
+}
 
+// 校验消息参数，通知消息还会校验 Style 和 ClickAction，Raw 消息解析后按相同规则校验
+func (s *MessageAndroid) Validate() error {
+	var errs ValidationErrors
 	if s.Type < TYPE_NOTIFICATION || s.Type > TYPE_MESSAGE {
-		return false
+		errs.add("message_type", fmt.Sprintf("must be %d or %d, got %d", TYPE_NOTIFICATION, TYPE_MESSAGE, s.Type))
 	}
 
-	if s.MultiPkg < 0 || s.MultiPkg > 1 {
-		return false
-	}
+	if s.Raw != "" {
+		// Raw 消息原样发送，解析后按结构化消息相同的规则校验
+		errs.addNested("raw", validateAndroidRaw(s.Raw, s.Type))
+	} else {
+		if s.Type == TYPE_NOTIFICATION {
+			if s.Style == nil {
+				errs.add("style", "required for notification")
+			} else {
+				errs.addNested("style", s.Style.Validate())
+			}
 
-	if s.Type == TYPE_NOTIFICATION {
-		if !s.Style.IsValid() {
-			return false
+			if s.ClickAction != nil {
+				errs.addNested("action", s.ClickAction.Validate())
+			}
 		}
 
-		if !s.ClickAction.IsValid() {
-			return false
-		}
+		validateAcceptTime(&errs, s.AcceptTime)
 	}
 
-	if s.ExpireTime < 0 || s.ExpireTime > 3*24*60*60 {
-		return false
+	validateSchedule(&errs, s.ExpireTime, s.SendTime)
+
+	if s.LoopInterval > 0 && s.LoopTimes > 0 && ((s.LoopTimes-1)*s.LoopInterval+1) > 15 {
+		errs.add("loop_times", "loop task must finish within 15 days")
 	}
 
-	_, err := time.Parse(DATETIMEFORMAT, s.SendTime)
-	if err != nil {
-		return false
+	return errs.err()
+}
+
+// 校验 Android 和 iOS 消息共有的 expire_time、send_time
+func validateSchedule(errs *ValidationErrors, expireTime int, sendTime string) {
+	if expireTime < 0 || expireTime > 3*24*60*60 {
+		errs.add("expire_time", fmt.Sprintf("must be between 0 and 259200 seconds, got %d", expireTime))
 	}
 
-	if s.AcceptTime != nil {
-		for _, v := range s.AcceptTime {
-			if !v.IsValid() {
-				return false
-			}
+	// 为空时推送接口使用当前时间
+	if sendTime != "" {
+		if _, err := time.Parse(DATETIMEFORMAT, sendTime); err != nil {
+			errs.add("send_time", "must be formatted as "+DATETIMEFORMAT)
 		}
 	}
+}
 
-	if s.LoopInterval > 0 && s.LoopTimes > 0 && ((s.LoopTimes-1)*s.LoopInterval+1) > 15 {
-		return false
+func validateAcceptTime(errs *ValidationErrors, acceptTime []TimeInterval) {
+	for i := range acceptTime {
+		errs.addNested(fmt.Sprintf("accept_time[%d]", i), acceptTime[i].Validate())
 	}
-
-	return true
 }
 
 func (s *MessageAndroid) ToJSON() string {
@@ -172,16 +209,17 @@ func (s *MessageAndroid) ToJSON() string {
 		jsonObj["content"] = s.Content
 
 		jsonObj["builder_id"] = s.Style.BuilderId
-		jsonObj["ring"] = s.Style.Ring
-		jsonObj["vibrate"] = s.Style.Vibrate
-		jsonObj["clearable"] = s.Style.Clearable
+		jsonObj["ring"] = boolInt(s.Style.Ring)
+		jsonObj["vibrate"] = boolInt(s.Style.Vibrate)
+		jsonObj["clearable"] = boolInt(s.Style.Clearable)
 		jsonObj["n_id"] = s.Style.NId
 		jsonObj["ring_raw"] = s.Style.RingRaw
-		jsonObj["lights"] = s.Style.Lights
+		jsonObj["lights"] = boolInt(s.Style.Lights)
 		jsonObj["icon_type"] = s.Style.IconType
 		jsonObj["icon_res"] = s.Style.IconRes
 		jsonObj["style_id"] = s.Style.StyleId
 		jsonObj["small_icon"] = s.Style.SmallIcon
+		s.Style.putExtra(jsonObj)
 
 		if s.ClickAction != nil {
 			jsonObj["action"] = s.ClickAction
@@ -212,28 +250,41 @@ type MessageIOS struct {
 	ExpireTime   int                    `json:"expire_time"`
 	SendTime     string                 `json:"send_time"`
 	AcceptTime   []TimeInterval         `json:"accept_time,omitempty"`
-	Type         int                    `json:"message_type"`
+	Type         MessageType            `json:"message_type"`
 	Custom       map[string]interface{} `json:"custom,omitempty"`
 	Raw          string                 `json:"raw,omitempty"`
 	AlertStr     string                 `json:"alert,omitempty"`
-	AlertJo      []string               `json:"alert,omitempty"`
-	Badge        int                    `json:"badge"`
+	Badge        *int                   `json:"badge"`
 	Sound        string                 `json:"sound"`
 	Category     string                 `json:"category"`
 	LoopInterval int                    `json:"loop_interval"`
 	LoopTimes    int                    `json:"loop_times"`
-	Environment  int                    `json:"environment"`
+	Environment  Environment            `json:"environment"`
+
+	// Deprecated: 字符串数组并不是合法的 APNs alert，不会被发送，只设置 AlertJo 时 Validate 返回错误，请使用 Alert 或 AlertStr
+	AlertJo []string `json:"alert_jo,omitempty"`
+
+	// aps 字典的其余字段，详见 Apple 的 Payload Key Reference
+	Alert             *ApsAlert `json:"alert_dict,omitempty"`         // alert 字典，设置后优先于 AlertStr
+	CriticalSound     *ApsSound `json:"critical_sound,omitempty"`     // sound 字典，设置后优先于 Sound
+	ContentAvailable  bool      `json:"content_available,omitempty"`  // 同时唤醒 App 在后台处理，TYPE_REMOTE_NOTIFICATION 总是包含
+	MutableContent    bool      `json:"mutable_content,omitempty"`    // 由 Notification Service Extension 修改内容
+	ThreadId          string    `json:"thread_id,omitempty"`          // 通知分组
+	TargetContentId   string    `json:"target_content_id,omitempty"`  // 点击后打开的窗口
+	InterruptionLevel string    `json:"interruption_level,omitempty"` // IOS_INTERRUPTION_*
+	RelevanceScore    *float64  `json:"relevance_score,omitempty"`    // 通知摘要中的排序权重，0~1
 }
 
 func NewMessageIOS() *MessageIOS {
 	return &MessageIOS{
 		Type:         TYPE_APNS_NOTIFICATION,
+		ExpireTime:   DEFAULT_EXPIRE_TIME,
 		SendTime:     time.Now().Format(DATETIMEFORMAT),
 		AcceptTime:   nil,
 		Raw:          "",
 		AlertStr:     "",
 		AlertJo:      make([]string, 0),
-		Badge:        1,
+		Badge:        intPtr(1),
 		Sound:        "beep.wav",
 		Category:     "",
 		LoopInterval: -1,
@@ -242,7 +293,7 @@ func NewMessageIOS() *MessageIOS {
 	}
 }
 
-func EasyMessageIOS(alert string, env int) *MessageIOS {
+func EasyMessageIOS(alert string, env Environment) *MessageIOS {
 	msg := NewMessageIOS()
 	msg.AlertStr = alert
 	msg.Environment = env
@@ -253,19 +304,50 @@ func (s *MessageIOS) SetAlert(alert string) {
 	s.AlertStr = alert
 }
 
+// 设置 alert 字典（标题、副标题、本地化 key 等），优先于 SetAlert 设置的字符串
+func (s *MessageIOS) SetApsAlert(alert *ApsAlert) {
+	s.Alert = alert
+}
+
+func (s *MessageIOS) SetMutableContent(mutableContent bool) {
+	s.MutableContent = mutableContent
+}
+
+func (s *MessageIOS) SetThreadId(threadId string) {
+	s.ThreadId = threadId
+}
+
+func (s *MessageIOS) SetTargetContentId(targetContentId string) {
+	s.TargetContentId = targetContentId
+}
+
+func (s *MessageIOS) SetInterruptionLevel(level string) {
+	s.InterruptionLevel = level
+}
+
+func (s *MessageIOS) SetRelevanceScore(score float64) {
+	s.RelevanceScore = &score
+}
+
+// 设置重要警告提示音，优先于 SetSound 设置的文件名
+func (s *MessageIOS) SetCriticalSound(sound *ApsSound) {
+	s.CriticalSound = sound
+}
+
 func (s *MessageIOS) SetCustom(custom map[string]interface{}) {
 	s.Custom = custom
 }
 
+// 设置角标数字，0 会清除 App 的角标；不需要修改角标时设置 Badge 为 nil
 func (s *MessageIOS) SetBadge(badge int) {
-	s.Badge = badge
+	s.Badge = &badge
 }
 
-func (s *MessageIOS) SetType(t int) {
+func (s *MessageIOS) SetType(t MessageType) {
 	s.Type = t
 }
 
-func (s *MessageIOS) SetEnvironment(env int) {
+func (s *MessageIOS) SetEnvironment(env Environment) {
 	s.Environment = env
 }
 
@@ -273,19 +355,39 @@ func (s *MessageIOS) SetSound(sourd string) {
 	s.Sound = sourd
 }
 
+// 设置通知的 category，对应 App 中注册的 UNNotificationCategory，用于显示自定义操作按钮
+func (s *MessageIOS) SetCategory(category string) {
+	s.Category = category
+}
+
+// 设置通知同时唤醒 App 在后台处理（aps.content-available），不需要展示内容时改用 TYPE_REMOTE_NOTIFICATION
+func (s *MessageIOS) SetContentAvailable(contentAvailable bool) {
+	s.ContentAvailable = contentAvailable
+}
+
 func (s *MessageIOS) AddAcceptTime(acceptTime TimeInterval) {
 	s.AcceptTime = append(s.AcceptTime, acceptTime)
 }
 
-func (s *MessageIOS) GetType() int {
+// 设置离线消息的存储时间（秒），最长 3 天，0 表示使用信鸽默认值
+func (s *MessageIOS) SetExpireTime(expireTime int) {
+	s.ExpireTime = expireTime
+}
+
+// 设置定时推送的时间，晚于当前时间时为定时推送，可用 CancelTimingPush 取消
+func (s *MessageIOS) SetSendTime(sendTime time.Time) {
+	s.SendTime = sendTime.Format(DATETIMEFORMAT)
+}
+
+func (s *MessageIOS) GetType() MessageType {
 	return s.Type
 }
 
-func (s *MessageIOS) GetMultiPkg() int {
-	return 1
+func (s *MessageIOS) GetMultiPkg() bool {
+	return true
 }
 
-func (s *MessageIOS) GetEnvironment() int {
+func (s *MessageIOS) GetEnvironment() Environment {
 	return s.Environment
 }
 
@@ -297,35 +399,36 @@ func (s *MessageIOS) GetLoopTimes() int {
 	return s.LoopTimes
 }
 
-func (s *MessageIOS) IsValid() bool {
-	if s.Raw == "" {
-		return true
-	}
+func (s *MessageIOS) GetExpireTime() int {
+	return s.ExpireTime
+}
 
-	if s.Type < TYPE_APNS_NOTIFICATION || s.Type > TYPE_REMOTE_NOTIFICATION {
-		return false
-	}
+func (s *MessageIOS) GetSendTime() string {
+	return s.SendTime
+}
 
-	if s.ExpireTime < 0 || s.ExpireTime > 3*24*60*60 {
-		return false
-	}
+func (s *MessageIOS) IsValid() bool {
+	return s.Validate() == nil
+}
 
-	_, err := time.Parse(DATETIMEFORMAT, s.SendTime)
-	if err != nil {
-		return false
+// 校验消息类型和 aps 字典，Raw 消息解析后按相同规则校验
+func (s *MessageIOS) Validate() error {
+	var errs ValidationErrors
+	if s.Type < TYPE_APNS_NOTIFICATION || s.Type > TYPE_REMOTE_NOTIFICATION {
+		errs.add("message_type", fmt.Sprintf("must be %d or %d, got %d", TYPE_APNS_NOTIFICATION, TYPE_REMOTE_NOTIFICATION, s.Type))
 	}
 
-	for _, v := range s.AcceptTime {
-		if !v.IsValid() {
-			return false
-		}
+	if s.Raw != "" {
+		// Raw 消息原样发送，解析后按结构化消息相同的规则校验
+		errs.addNested("raw", validateIOSRaw(s.Raw, s.Type))
+	} else {
+		s.validateAps(&errs)
+		validateAcceptTime(&errs, s.AcceptTime)
 	}
 
-	if s.Type == TYPE_REMOTE_NOTIFICATION {
-		return true
-	}
+	validateSchedule(&errs, s.ExpireTime, s.SendTime)
 
-	return s.AlertStr != "" || len(s.AlertJo) > 0
+	return errs.err()
 }
 
 func (s *MessageIOS) ToJSON() string {
@@ -346,21 +449,47 @@ func (s *MessageIOS) ToJSON() string {
 	if s.Type == TYPE_REMOTE_NOTIFICATION {
 		aps["content-available"] = 1
 	} else if s.Type == TYPE_APNS_NOTIFICATION {
-		if len(s.AlertJo) > 0 {
-			aps["alert"] = s.AlertJo
+		if s.Alert != nil {
+			aps["alert"] = s.Alert
 		} else {
 			aps["alert"] = s.AlertStr
 		}
 
-		if s.Badge != 0 {
-			aps["badge"] = s.Badge
+		if s.Badge != nil {
+			aps["badge"] = *s.Badge
 		}
 
-		if s.Sound != "" {
+		if s.CriticalSound != nil {
+			aps["sound"] = s.CriticalSound
+		} else if s.Sound != "" {
 			aps["sound"] = s.Sound
 		}
 
-		if s.Category == "" {
+		if s.ContentAvailable {
+			aps["content-available"] = 1
+		}
+
+		if s.MutableContent {
+			aps["mutable-content"] = 1
+		}
+
+		if s.ThreadId != "" {
+			aps["thread-id"] = s.ThreadId
+		}
+
+		if s.TargetContentId != "" {
+			aps["target-content-id"] = s.TargetContentId
+		}
+
+		if s.InterruptionLevel != "" {
+			aps["interruption-level"] = s.InterruptionLevel
+		}
+
+		if s.RelevanceScore != nil {
+			aps["relevance-score"] = *s.RelevanceScore
+		}
+
+		if s.Category != "" {
 			aps["category"] = s.Category
 		}
 	}
@@ -372,3 +501,7 @@ func (s *MessageIOS) ToJSON() string {
 	}
 	return string(byt)
 }
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,126 @@
+package xinge
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// MessageIOS.ToJSON 在 alert/badge/sound/category/content-available 各种组合下的输出，
+// 与 testdata/ios_tojson 下的 golden 文件逐字节比较，修改输出格式后用 go test -update 重新生成
+func TestMessageIOSToJSONGolden(t *testing.T) {
+	goldens := make(map[string]string)
+	for combo := 0; combo < 32; combo++ {
+		alert, badge, sound, category, contentAvailable := combo&1 != 0, combo&2 != 0, combo&4 != 0, combo&8 != 0, combo&16 != 0
+
+		msg := NewMessageIOS()
+		msg.Badge = nil
+		msg.SetSound("")
+		if alert {
+			msg.SetAlert("您有一条新消息")
+		}
+		if badge {
+			msg.SetBadge(5)
+		}
+		if sound {
+			msg.SetSound("default")
+		}
+		if category {
+			msg.SetCategory("MEETING_INVITATION")
+		}
+		if contentAvailable {
+			msg.SetContentAvailable(true)
+		}
+
+		name := fmt.Sprintf("alert%d_badge%d_sound%d_category%d_content%d.json",
+			b2i(alert), b2i(badge), b2i(sound), b2i(category), b2i(contentAvailable))
+
+		// 没有 alert 的 APNs 通知不能发送，仍记录 ToJSON 的输出
+		if alert {
+			if err := msg.Validate(); err != nil {
+				t.Errorf("%s: Validate() = %v", name, err)
+			}
+		} else if got := validationFields(t, msg.Validate()); !reflect.DeepEqual(got, []string{"aps.alert"}) {
+			t.Errorf("%s: invalid fields = %v, want [aps.alert]", name, got)
+		}
+
+		goldens[name] = checkGolden(t, name, msg.ToJSON())
+	}
+
+	// content-available 与其余 aps 字段同时输出
+	for name, got := range goldens {
+		if strings.HasSuffix(name, "_content1.json") && got == goldens[strings.Replace(name, "_content1", "_content0", 1)] {
+			t.Errorf("%s: same output as without content-available: %s", name, got)
+		}
+	}
+}
+
+// 静默推送（TYPE_REMOTE_NOTIFICATION）的 aps 只有 content-available，badge/sound/category 不输出
+func TestMessageIOSRemoteNotificationToJSONGolden(t *testing.T) {
+	var first string
+	for combo := 0; combo < 8; combo++ {
+		badge, sound, category := combo&1 != 0, combo&2 != 0, combo&4 != 0
+
+		msg := NewMessageIOS()
+		msg.SetType(TYPE_REMOTE_NOTIFICATION)
+		msg.Badge = nil
+		msg.SetSound("")
+		msg.SetCustom(map[string]interface{}{"sync": "inbox"})
+		if badge {
+			msg.SetBadge(5)
+		}
+		if sound {
+			msg.SetSound("default")
+		}
+		if category {
+			msg.SetCategory("MEETING_INVITATION")
+		}
+
+		name := fmt.Sprintf("remote_badge%d_sound%d_category%d.json", b2i(badge), b2i(sound), b2i(category))
+		if err := msg.Validate(); err != nil {
+			t.Errorf("%s: Validate() = %v", name, err)
+		}
+
+		got := checkGolden(t, name, msg.ToJSON())
+		if combo == 0 {
+			first = got
+		} else if got != first {
+			t.Errorf("%s: output differs from remote_badge0_sound0_category0.json: %s", name, got)
+		}
+	}
+}
+
+// 将 got 与 testdata/ios_tojson/name 比较，-update 时改为写入，返回带换行的 got
+func checkGolden(t *testing.T, name, got string) string {
+	t.Helper()
+	got += "\n"
+	path := filepath.Join("testdata", "ios_tojson", name)
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v (run go test -update to create it)", name, err)
+	}
+	if got != string(want) {
+		t.Errorf("%s:\ngot  %s\nwant %s", name, got, want)
+	}
+	return got
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
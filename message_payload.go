@@ -0,0 +1,252 @@
+package xinge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// MessageAndroid.ToJSON 输出的消息结构，样式字段平铺在顶层，Raw 消息按此结构解析
+type androidPayload struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	styleJSON
+	ClickAction *ClickAction           `json:"action,omitempty"`
+	AcceptTime  []TimeInterval         `json:"accept_time,omitempty"`
+	Custom      map[string]interface{} `json:"custom_content,omitempty"`
+}
+
+// MessageIOS.ToJSON 输出的消息结构，Raw 消息按此结构解析
+type iosPayload struct {
+	Aps        *apsPayload            `json:"aps"`
+	AcceptTime []TimeInterval         `json:"accept_time,omitempty"`
+	Custom     map[string]interface{} `json:"custom,omitempty"`
+}
+
+// APNs 的 aps 字典
+type apsPayload struct {
+	Alert             json.RawMessage `json:"alert,omitempty"`
+	Badge             *int            `json:"badge,omitempty"`
+	Sound             json.RawMessage `json:"sound,omitempty"`
+	Category          string          `json:"category,omitempty"`
+	ContentAvailable  int             `json:"content-available,omitempty"`
+	MutableContent    int             `json:"mutable-content,omitempty"`
+	ThreadId          string          `json:"thread-id,omitempty"`
+	TargetContentId   string          `json:"target-content-id,omitempty"`
+	InterruptionLevel string          `json:"interruption-level,omitempty"`
+	RelevanceScore    *float64        `json:"relevance-score,omitempty"`
+}
+
+func decodeAndroidPayload(raw string) (*androidPayload, error) {
+	var p androidPayload
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func decodeIOSPayload(raw string) (*iosPayload, error) {
+	var p iosPayload
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// 按结构化消息的规则校验 Android Raw 消息，字段路径使用 JSON 中的 key
+func validateAndroidRaw(raw string, messageType MessageType) error {
+	p, err := decodeAndroidPayload(raw)
+	if err != nil {
+		return newValidationError("", "invalid JSON: "+err.Error())
+	}
+
+	var errs ValidationErrors
+	if messageType == TYPE_NOTIFICATION {
+		errs.addNested("", p.styleJSON.validate())
+		if p.ClickAction != nil {
+			errs.addNested("action", p.ClickAction.Validate())
+		}
+	}
+	validateAcceptTime(&errs, p.AcceptTime)
+	return errs.err()
+}
+
+// 按结构化消息的规则校验 iOS Raw 消息，字段路径使用 JSON 中的 key
+func validateIOSRaw(raw string, messageType MessageType) error {
+	p, err := decodeIOSPayload(raw)
+	if err != nil {
+		return newValidationError("", "invalid JSON: "+err.Error())
+	}
+
+	var errs ValidationErrors
+	if p.Aps == nil {
+		errs.add("aps", "required")
+		return errs.err()
+	}
+
+	if messageType == TYPE_REMOTE_NOTIFICATION && p.Aps.ContentAvailable != 1 {
+		errs.add("aps.content-available", "must be 1 for remote notification")
+	}
+
+	checkSwitch(&errs, "aps.content-available", p.Aps.ContentAvailable)
+	checkSwitch(&errs, "aps.mutable-content", p.Aps.MutableContent)
+
+	if msg, err := messageIOSFromPayload(p); err != nil {
+		errs.addNested("", err)
+	} else {
+		msg.Type = messageType
+		if messageType == TYPE_APNS_NOTIFICATION && msg.Alert == nil && isEmptyJSON(p.Aps.Alert) {
+			errs.add("aps.alert", "required for APNS notification")
+		} else {
+			msg.validateAps(&errs)
+		}
+	}
+	validateAcceptTime(&errs, p.AcceptTime)
+	return errs.err()
+}
+
+// JSON 值的第一个字符，用于区分字符串、对象和数组
+func jsonKind(v json.RawMessage) byte {
+	v = bytes.TrimSpace(v)
+	if len(v) == 0 {
+		return 0
+	}
+	return v[0]
+}
+
+// 缺失、null、空字符串、空数组或空对象
+func isEmptyJSON(v json.RawMessage) bool {
+	switch string(bytes.TrimSpace(v)) {
+	case "", "null", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// Style 平铺到 Android 消息顶层的字段，出现任意一个即视为通知
+var androidStyleKeys = []string{
+	"builder_id", "ring", "vibrate", "clearable", "n_id", "ring_raw",
+	"lights", "icon_type", "icon_res", "style_id", "small_icon",
+	"n_ch_id", "n_ch_name", "hw_ch_id", "xm_ch_id", "oppo_ch_id", "vivo_ch_id", "meizu_ch_id",
+	"badge_type", "big_text", "big_picture",
+}
+
+/**
+ * 解析 Android 消息 JSON，是 MessageAndroid.ToJSON 的逆操作，可用于加载保存过的消息或运营编写的消息模板
+ *
+ * 包含样式字段（builder_id、ring、n_id 等）或 action 时解析为通知，否则为透传消息。
+ * JSON 中不包含的字段（ExpireTime、SendTime、MultiPkg、LoopInterval、LoopTimes）取零值或 NewMessageAndroid 的默认值
+ *
+ * @param data 消息 JSON
+ * @return 解析出的消息，JSON 不合法时返回 *ValidationError
+ */
+func ParseMessageAndroid(data []byte) (*MessageAndroid, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, newValidationError("message", "invalid JSON: "+err.Error())
+	}
+	p, err := decodeAndroidPayload(string(data))
+	if err != nil {
+		return nil, newValidationError("message", "invalid JSON: "+err.Error())
+	}
+
+	msg := &MessageAndroid{
+		Title:        p.Title,
+		Content:      p.Content,
+		AcceptTime:   p.AcceptTime,
+		Type:         TYPE_MESSAGE,
+		Custom:       p.Custom,
+		LoopInterval: -1,
+		LoopTimes:    -1,
+	}
+
+	_, hasAction := keys["action"]
+	isNotification := hasAction
+	for _, k := range androidStyleKeys {
+		if _, ok := keys[k]; ok {
+			isNotification = true
+		}
+	}
+	if isNotification {
+		msg.Type = TYPE_NOTIFICATION
+		msg.Style = p.styleJSON.style()
+		msg.ClickAction = p.ClickAction
+	}
+	return msg, nil
+}
+
+/**
+ * 解析 iOS 消息 JSON，是 MessageIOS.ToJSON 的逆操作，可用于加载保存过的消息或运营编写的消息模板
+ *
+ * aps 中只有 content-available 时解析为静默推送（TYPE_REMOTE_NOTIFICATION），否则为 APNs 通知。
+ * JSON 中不包含的字段（ExpireTime、SendTime、Environment）取零值，推送前需调用 SetEnvironment 设置推送环境
+ *
+ * @param data 消息 JSON
+ * @return 解析出的消息，JSON 不合法时返回 *ValidationError
+ */
+func ParseMessageIOS(data []byte) (*MessageIOS, error) {
+	p, err := decodeIOSPayload(string(data))
+	if err != nil {
+		return nil, newValidationError("message", "invalid JSON: "+err.Error())
+	}
+	if p.Aps == nil {
+		return nil, newValidationError("aps", "required")
+	}
+
+	return messageIOSFromPayload(p)
+}
+
+// 把解析出的 iOS 消息结构转换成 MessageIOS，alert 和 sound 可以是字符串或字典
+func messageIOSFromPayload(p *iosPayload) (*MessageIOS, error) {
+	msg := &MessageIOS{
+		AcceptTime:        p.AcceptTime,
+		Type:              TYPE_APNS_NOTIFICATION,
+		Custom:            p.Custom,
+		Badge:             p.Aps.Badge,
+		Category:          p.Aps.Category,
+		LoopInterval:      -1,
+		LoopTimes:         -1,
+		MutableContent:    p.Aps.MutableContent == 1,
+		ThreadId:          p.Aps.ThreadId,
+		TargetContentId:   p.Aps.TargetContentId,
+		InterruptionLevel: p.Aps.InterruptionLevel,
+		RelevanceScore:    p.Aps.RelevanceScore,
+	}
+
+	if len(p.Aps.Sound) > 0 {
+		var err error
+		switch jsonKind(p.Aps.Sound) {
+		case '"':
+			err = json.Unmarshal(p.Aps.Sound, &msg.Sound)
+		case '{':
+			err = json.Unmarshal(p.Aps.Sound, &msg.CriticalSound)
+		default:
+			err = errors.New("unsupported type")
+		}
+		if err != nil {
+			return nil, newValidationError("aps.sound", "must be a string or a sound dictionary")
+		}
+	}
+
+	if len(p.Aps.Alert) == 0 {
+		if p.Aps.ContentAvailable == 1 {
+			msg.Type = TYPE_REMOTE_NOTIFICATION
+		}
+		return msg, nil
+	}
+
+	msg.ContentAvailable = p.Aps.ContentAvailable == 1
+	var err error
+	switch jsonKind(p.Aps.Alert) {
+	case '"':
+		err = json.Unmarshal(p.Aps.Alert, &msg.AlertStr)
+	case '{':
+		err = json.Unmarshal(p.Aps.Alert, &msg.Alert)
+	default:
+		err = errors.New("unsupported type")
+	}
+	if err != nil {
+		return nil, newValidationError("aps.alert", "must be a string or an alert dictionary")
+	}
+	return msg, nil
+}
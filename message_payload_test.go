@@ -0,0 +1,190 @@
+package xinge
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+const roundTripIterations = 500
+
+func randString(r *rand.Rand) string {
+	runes := []rune("abcXYZ 0123 推送消息标题 &=+%\"\\/\n🎉")
+	b := make([]rune, r.Intn(12))
+	for i := range b {
+		b[i] = runes[r.Intn(len(runes))]
+	}
+	return string(b)
+}
+
+func randCustom(r *rand.Rand) map[string]interface{} {
+	if r.Intn(3) == 0 {
+		return nil
+	}
+	custom := map[string]interface{}{}
+	for i := r.Intn(4); i > 0; i-- {
+		switch r.Intn(3) {
+		case 0:
+			custom[randString(r)] = randString(r)
+		case 1:
+			custom[randString(r)] = float64(r.Intn(1000))
+		default:
+			custom[randString(r)] = r.Intn(2) == 0
+		}
+	}
+	return custom
+}
+
+func randAcceptTime(r *rand.Rand) []TimeInterval {
+	if r.Intn(3) == 0 {
+		return nil
+	}
+	list := make([]TimeInterval, r.Intn(3))
+	for i := range list {
+		list[i] = TimeInterval{&TimePart{r.Intn(24), r.Intn(60)}, &TimePart{r.Intn(24), r.Intn(60)}}
+	}
+	return list
+}
+
+func randClickAction(r *rand.Rand) *ClickAction {
+	if r.Intn(3) == 0 {
+		return nil
+	}
+	action := &ClickAction{
+		ActionType:  ActionType(1 + r.Intn(3)),
+		Activity:    randString(r),
+		Intent:      randString(r),
+		PackageName: randString(r),
+	}
+	if r.Intn(2) == 0 {
+		action.Browser = &Browser{Url: randString(r), ConfirmOnUrl: r.Intn(2) == 1}
+	}
+	if r.Intn(2) == 0 {
+		action.AtyAttr = &AtyAttr{AtyAttrIntentFlag: r.Intn(100), AtyAttrPendingIntentFlag: r.Intn(100)}
+	}
+	return action
+}
+
+// 只包含 ToJSON 会输出的字段，其余字段取 ParseMessageAndroid 的默认值
+func randMessageAndroid(r *rand.Rand) *MessageAndroid {
+	msg := &MessageAndroid{
+		Title:        randString(r),
+		Content:      randString(r),
+		AcceptTime:   randAcceptTime(r),
+		Type:         TYPE_MESSAGE,
+		Custom:       randCustom(r),
+		LoopInterval: -1,
+		LoopTimes:    -1,
+	}
+	if r.Intn(4) != 0 {
+		msg.Type = TYPE_NOTIFICATION
+		msg.Style = &Style{
+			BuilderId: r.Intn(10),
+			Ring:      r.Intn(2) == 1,
+			Vibrate:   r.Intn(2) == 1,
+			Clearable: r.Intn(2) == 1,
+			NId:       r.Intn(100) - 1,
+			RingRaw:   randString(r),
+			Lights:    r.Intn(2) == 1,
+			IconType:  r.Intn(2),
+			IconRes:   randString(r),
+			StyleId:   r.Intn(2),
+			SmallIcon: randString(r),
+		}
+		if r.Intn(2) == 0 {
+			msg.Style.SetChannel(randString(r), randString(r))
+			msg.Style.SetVendorChannels(VendorChannels{HuaweiChannelId: randString(r), XiaomiChannelId: randString(r), OppoChannelId: randString(r)})
+			msg.Style.SetBadgeType(r.Intn(100) - 2)
+			msg.Style.SetBigText(randString(r))
+		}
+		msg.ClickAction = randClickAction(r)
+	}
+	return msg
+}
+
+// 只包含 ToJSON 会输出的字段，其余字段取 ParseMessageIOS 的默认值
+func randMessageIOS(r *rand.Rand) *MessageIOS {
+	msg := &MessageIOS{
+		AcceptTime:   randAcceptTime(r),
+		Type:         TYPE_REMOTE_NOTIFICATION,
+		Custom:       randCustom(r),
+		LoopInterval: -1,
+		LoopTimes:    -1,
+	}
+	if r.Intn(4) != 0 {
+		msg.Type = TYPE_APNS_NOTIFICATION
+		if r.Intn(2) == 0 {
+			msg.AlertStr = randString(r)
+		} else {
+			msg.Alert = &ApsAlert{
+				Title:        randString(r),
+				Subtitle:     randString(r),
+				Body:         randString(r),
+				LaunchImage:  randString(r),
+				TitleLocKey:  randString(r),
+				TitleLocArgs: []string{randString(r)},
+				LocKey:       randString(r),
+				LocArgs:      []string{randString(r), randString(r)},
+			}
+		}
+		if r.Intn(4) != 0 {
+			msg.Badge = intPtr(r.Intn(100))
+		}
+		if r.Intn(3) == 0 {
+			msg.CriticalSound = NewCriticalSound(randString(r), float64(r.Intn(11))/10)
+		} else {
+			msg.Sound = randString(r)
+		}
+		msg.Category = randString(r)
+		msg.ContentAvailable = r.Intn(2) == 1
+		msg.MutableContent = r.Intn(2) == 1
+		msg.ThreadId = randString(r)
+		msg.TargetContentId = randString(r)
+		msg.InterruptionLevel = []string{"", IOS_INTERRUPTION_PASSIVE, IOS_INTERRUPTION_TIME_SENSITIVE}[r.Intn(3)]
+		if r.Intn(2) == 0 {
+			score := r.Float64()
+			msg.RelevanceScore = &score
+		}
+	}
+	return msg
+}
+
+func TestParseMessageAndroidRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < roundTripIterations; i++ {
+		want := randMessageAndroid(r)
+		got, err := ParseMessageAndroid([]byte(want.ToJSON()))
+		if err != nil {
+			t.Fatalf("ParseMessageAndroid(%s): %v", want.ToJSON(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("round trip mismatch for %s\ngot  %+v\nwant %+v", want.ToJSON(), got, want)
+		}
+	}
+}
+
+func TestParseMessageIOSRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < roundTripIterations; i++ {
+		want := randMessageIOS(r)
+		got, err := ParseMessageIOS([]byte(want.ToJSON()))
+		if err != nil {
+			t.Fatalf("ParseMessageIOS(%s): %v", want.ToJSON(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("round trip mismatch for %s\ngot  %+v\nwant %+v", want.ToJSON(), got, want)
+		}
+	}
+}
+
+func TestParseMessageInvalid(t *testing.T) {
+	if _, err := ParseMessageAndroid([]byte(`{"title":`)); err == nil {
+		t.Errorf("ParseMessageAndroid accepted invalid JSON")
+	}
+	if _, err := ParseMessageIOS([]byte(`{"custom":{}}`)); err == nil {
+		t.Errorf("ParseMessageIOS accepted message without aps")
+	}
+	if _, err := ParseMessageIOS([]byte(`{"aps":{"alert":42}}`)); err == nil {
+		t.Errorf("ParseMessageIOS accepted numeric alert")
+	}
+}
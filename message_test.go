@@ -0,0 +1,247 @@
+package xinge
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validationFields(t *testing.T, err error) []string {
+	t.Helper()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want ValidationErrors", err)
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	return fields
+}
+
+func TestMessageAndroidValidate(t *testing.T) {
+	if err := EasyMessageAndroid("title", "content").Validate(); err != nil {
+		t.Fatalf("EasyMessageAndroid: %v", err)
+	}
+
+	msg := EasyMessageAndroid("title", "content")
+	msg.Style.IconType = 2
+	msg.ClickAction.SetActionType(TYPE_URL)
+	msg.ExpireTime = -1
+	msg.AddAcceptTime(*DefaultTimeInterval())
+	msg.AddAcceptTime(TimeInterval{StartTime: &TimePart{8, 0}, EndTime: &TimePart{24, 60}})
+	msg.LoopInterval, msg.LoopTimes = 2, 10
+
+	err := msg.Validate()
+	if !errors.Is(err, ErrInvalidParam) || msg.IsValid() {
+		t.Fatalf("Validate = %v, want ErrInvalidParam", err)
+	}
+	want := []string{"style.icon_type", "action.browser.url", "accept_time[1].end.hour", "accept_time[1].end.min", "expire_time", "loop_times"}
+	if got := validationFields(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestClickActionValidate(t *testing.T) {
+	if got := NewSimplekAction("com.example.app", ".MainActivity"); got.PackageName != "com.example.app" || got.Validate() != nil {
+		t.Errorf("NewSimplekAction = %+v", got)
+	}
+
+	attr := NewAtyAttr()
+	attr.SetAtyAttrPendingIntentFlag(4)
+	if attr.AtyAttrPendingIntentFlag != 4 || attr.AtyAttrIntentFlag != 0 {
+		t.Errorf("SetAtyAttrPendingIntentFlag = %+v", attr)
+	}
+
+	cases := []struct {
+		action *ClickAction
+		want   []string
+	}{
+		{NewUrlAction("https://example.com/a?b=c", true), nil},
+		{NewUrlAction("example.com", false), []string{"browser.url"}},
+		{NewIntentAction("myapp://orders/detail"), nil},
+		{NewIntentAction("orders/detail"), []string{"intent"}},
+		{NewIntentAction("intent://orders#Intent;scheme=myapp"), []string{"intent"}},
+		{NewIntentAction("intent://orders#Intent;scheme=myapp;package=1app;end"), []string{"intent"}},
+		{NewSimplekAction("example", "com.example.Main Activity"), []string{"activity", "package_name"}},
+	}
+	for _, tc := range cases {
+		err := tc.action.Validate()
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tc.action, err)
+			}
+			continue
+		}
+		if got := validationFields(t, err); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%+v: fields = %v, want %v", tc.action, got, tc.want)
+		}
+	}
+}
+
+func TestMessageAndroidChannelStyle(t *testing.T) {
+	msg := EasyMessageAndroid("title", "content")
+	msg.Style.SetChannel("orders", "订单通知")
+	msg.Style.SetVendorChannels(VendorChannels{HuaweiChannelId: "hw_orders", XiaomiChannelId: "xm_orders"})
+	msg.Style.SetBadgeType(ANDROID_BADGE_INCREASE)
+	msg.Style.SetBigPicture("https://example.com/banner.png")
+	if err := msg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.ToJSON()), &obj); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]interface{}{"n_ch_id": "orders", "n_ch_name": "订单通知", "hw_ch_id": "hw_orders", "xm_ch_id": "xm_orders", "badge_type": -2.0, "big_picture": "https://example.com/banner.png"} {
+		if obj[k] != v {
+			t.Errorf("ToJSON %s = %v, want %v", k, obj[k], v)
+		}
+	}
+	if _, ok := obj["oppo_ch_id"]; ok {
+		t.Errorf("ToJSON emitted unset oppo_ch_id")
+	}
+
+	msg.Style.ChannelName = ""
+	msg.Style.OppoChannelId = "oppo orders"
+	msg.Style.SetBadgeType(-3)
+	msg.Style.SetBigText("long text")
+	msg.Style.SetBigPicture("ftp://example.com/banner.png")
+	want := []string{"style.n_ch_name", "style.oppo_ch_id", "style.badge_type", "style.big_picture", "style.big_picture"}
+	if got := validationFields(t, msg.Validate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestMessageAndroidValidateRaw(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want []string
+	}{
+		{`{"title":"t","content":"c","ring":1,"action":{"action_type":1}}`, nil},
+		{`{"title":"t","content":"c","ring":3,"vibrate":-1}`, []string{"raw.ring", "raw.vibrate"}},
+		{`{"action":{"action_type":3},"accept_time":[{"start":{"hour":25,"min":0},"end":{"hour":1,"min":0}}]}`,
+			[]string{"raw.action.intent", "raw.accept_time[0].start.hour"}},
+		{`{"title":`, []string{"raw"}},
+	}
+	for _, tc := range cases {
+		msg := NewMessageAndroid()
+		msg.Raw = tc.raw
+		// Raw 消息忽略结构体中的样式字段
+		msg.Style.IconType = 5
+
+		err := msg.Validate()
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("raw %s: unexpected error %v", tc.raw, err)
+			}
+			continue
+		}
+		if got := validationFields(t, err); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("raw %s: fields = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestMessageIOSValidate(t *testing.T) {
+	if err := EasyMessageIOS("alert", IOSENV_DEV).Validate(); err != nil {
+		t.Fatalf("EasyMessageIOS: %v", err)
+	}
+	if got := validationFields(t, EasyMessageIOS("", IOSENV_DEV).Validate()); !reflect.DeepEqual(got, []string{"aps.alert"}) {
+		t.Errorf("empty alert fields = %v", got)
+	}
+
+	cases := []struct {
+		raw  string
+		typ  MessageType
+		want []string
+	}{
+		{`{"aps":{"alert":"hello","badge":1}}`, TYPE_APNS_NOTIFICATION, nil},
+		{`{"aps":{"alert":{"title":"t","body":"b"}}}`, TYPE_APNS_NOTIFICATION, nil},
+		{`{"aps":{"content-available":1}}`, TYPE_REMOTE_NOTIFICATION, nil},
+		{`{"aps":{"alert":{"loc-key":"NEW_MSG","loc-args":["Tom"]},"mutable-content":1,"interruption-level":"time-sensitive"}}`, TYPE_APNS_NOTIFICATION, nil},
+		{`{"aps":{"alert":""}}`, TYPE_APNS_NOTIFICATION, []string{"raw.aps.alert"}},
+		{`{"aps":{"alert":["hello","world"]}}`, TYPE_APNS_NOTIFICATION, []string{"raw.aps.alert"}},
+		{`{"aps":{"alert":{"loc-args":["Tom"]},"sound":{"critical":1,"volume":2},"relevance-score":1.5}}`, TYPE_APNS_NOTIFICATION,
+			[]string{"raw.aps.alert", "raw.aps.alert.loc-args", "raw.aps.sound.name", "raw.aps.sound.volume", "raw.aps.relevance-score"}},
+		{`{"aps":{"alert":"hello","mutable-content":2}}`, TYPE_APNS_NOTIFICATION, []string{"raw.aps.mutable-content"}},
+		{`{"aps":{"alert":"hello","sound":1,"mutable-content":2}}`, TYPE_APNS_NOTIFICATION, []string{"raw.aps.mutable-content", "raw.aps.sound"}},
+		{`{"aps":{}}`, TYPE_REMOTE_NOTIFICATION, []string{"raw.aps.content-available"}},
+		{`{"custom":{"k":"v"}}`, TYPE_APNS_NOTIFICATION, []string{"raw.aps"}},
+		{`not json`, TYPE_APNS_NOTIFICATION, []string{"raw"}},
+	}
+	for _, tc := range cases {
+		msg := NewMessageIOS()
+		msg.Raw = tc.raw
+		msg.Type = tc.typ
+
+		err := msg.Validate()
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("raw %s: unexpected error %v", tc.raw, err)
+			}
+			continue
+		}
+		if got := validationFields(t, err); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("raw %s: fields = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestMessageIOSApsValidate(t *testing.T) {
+	msg := EasyMessageIOS("", IOSENV_DEV)
+	msg.SetApsAlert(&ApsAlert{Title: "title", Subtitle: "subtitle", Body: "body", TitleLocArgs: []string{"x"}})
+	msg.SetCriticalSound(NewCriticalSound("alarm.caf", 0.8))
+	msg.SetInterruptionLevel("urgent")
+	msg.SetRelevanceScore(-0.1)
+
+	want := []string{"aps.alert.title-loc-args", "aps.interruption-level", "aps.relevance-score"}
+	if got := validationFields(t, msg.Validate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+
+	// 角标为 0 时也要发送，用于清除角标
+	clear := EasyMessageIOS("hello", IOSENV_DEV)
+	clear.SetBadge(0)
+	if !strings.Contains(clear.ToJSON(), `"badge":0`) {
+		t.Errorf("SetBadge(0): ToJSON = %s", clear.ToJSON())
+	}
+	clear.Badge = nil
+	if strings.Contains(clear.ToJSON(), `"badge"`) {
+		t.Errorf("nil Badge: ToJSON = %s", clear.ToJSON())
+	}
+
+	// 字符串数组不是合法的 alert，不再发送
+	alertJo := EasyMessageIOS("", IOSENV_DEV)
+	alertJo.AlertJo = []string{"hello", "world"}
+	if got := validationFields(t, alertJo.Validate()); !reflect.DeepEqual(got, []string{"aps.alert"}) {
+		t.Errorf("AlertJo only: fields = %v, want [aps.alert]", got)
+	}
+	if strings.Contains(alertJo.ToJSON(), "hello") {
+		t.Errorf("AlertJo sent: %s", alertJo.ToJSON())
+	}
+
+	msg.Alert.TitleLocArgs = nil
+	msg.SetMutableContent(true)
+	msg.SetInterruptionLevel(IOS_INTERRUPTION_CRITICAL)
+	msg.SetRelevanceScore(0.5)
+	msg.SetThreadId("chat-1")
+	if err := msg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	var payload struct {
+		Aps map[string]interface{} `json:"aps"`
+	}
+	if err := json.Unmarshal([]byte(msg.ToJSON()), &payload); err != nil {
+		t.Fatal(err)
+	}
+	alert, _ := payload.Aps["alert"].(map[string]interface{})
+	sound, _ := payload.Aps["sound"].(map[string]interface{})
+	if alert["subtitle"] != "subtitle" || sound["name"] != "alarm.caf" || payload.Aps["mutable-content"] != 1.0 ||
+		payload.Aps["thread-id"] != "chat-1" || payload.Aps["interruption-level"] != "critical" || payload.Aps["relevance-score"] != 0.5 {
+		t.Errorf("ToJSON = %s", msg.ToJSON())
+	}
+}
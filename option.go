@@ -0,0 +1,49 @@
+package xinge
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client 的可选配置项，在 NewClient 时传入
+type Option func(*Client)
+
+// 使用自定义的 http.Client 发起请求（如走出口代理、注入 mTLS 证书），默认 http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// 使用自定义的 RoundTripper 发起请求，作用在 http.Client 的副本上，不影响原实例
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// 替换信鸽接口的域名部分，默认 RESTAPI_DOMAIN，测试时可指向本地的模拟服务
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// 设置请求头中的 User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// 设置单次 HTTP 请求的超时时间（包括连接、发送和读取响应）
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
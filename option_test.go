@@ -0,0 +1,59 @@
+package xinge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	var gotPath, gotUA, gotSign string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotPath = r.URL.Path
+		gotUA = r.UserAgent()
+		gotSign = r.PostForm.Get("sign")
+		w.Write([]byte(`{"ret_code":0,"result":{"device_num":42}}`))
+	}))
+	defer srv.Close()
+
+	rt := &countingTransport{}
+	hc := &http.Client{}
+	c := NewClient(2100000000, "secret",
+		WithHTTPClient(hc),
+		WithTransport(rt),
+		WithBaseURL(srv.URL+"/"),
+		WithUserAgent("xinge-test/1.0"),
+		WithTimeout(5*time.Second),
+	)
+
+	resp := c.QueryDeviceCount()
+	if resp.Code != 0 || resp.XgResult == nil || resp.XgResult.DeviceNum != 42 {
+		t.Fatalf("QueryDeviceCount = %+v", resp)
+	}
+	if gotPath != "/v2/application/get_app_device_num" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotUA != "xinge-test/1.0" {
+		t.Errorf("user agent = %q", gotUA)
+	}
+	if gotSign == "" {
+		t.Errorf("sign missing")
+	}
+	if rt.calls != 1 {
+		t.Errorf("transport calls = %d, want 1", rt.calls)
+	}
+	if hc.Transport != nil || hc.Timeout != 0 {
+		t.Errorf("caller's http.Client was modified: %+v", hc)
+	}
+}
@@ -0,0 +1,129 @@
+package xinge
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	IOS_MAX_PAYLOAD     = 4096 // APNs 限制推送内容最大 4KB
+	ANDROID_MAX_PAYLOAD = 4096 // 经验值：信鸽文档未给出 Android 消息的上限（超限返回 RETCODE_MESSAGE_TOO_LONG），取与 APNs 相同的 4KB
+
+	TRUNCATE_ELLIPSIS = "…"
+)
+
+// 所有 *PayloadTooLargeError 都满足 errors.Is(err, ErrPayloadTooLarge)
+var ErrPayloadTooLarge = errors.New("xinge: payload too large")
+
+// 消息编码后超出平台限制的错误
+type PayloadTooLargeError struct {
+	Platform string // android 或 ios
+	Size     int    // 编码后的字节数
+	Limit    int    // 平台允许的最大字节数
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("xinge: %s payload is %d bytes, exceeds limit of %d bytes", e.Platform, e.Size, e.Limit)
+}
+
+func (e *PayloadTooLargeError) Is(target error) bool {
+	return target == ErrPayloadTooLarge
+}
+
+// 设置 Android 和 iOS 消息编码后的最大字节数，默认 ANDROID_MAX_PAYLOAD 和 IOS_MAX_PAYLOAD，小于等于 0 表示不限制
+func WithPayloadLimit(android, ios int) Option {
+	return func(c *Client) {
+		c.androidPayloadLimit = android
+		c.iosPayloadLimit = ios
+	}
+}
+
+// 消息超长时自动截断 Android 的 Content 或 iOS 的 alert 正文（按字符截断并追加省略号），
+// 默认不截断，直接返回 *PayloadTooLargeError。截断作用在消息副本上，不修改调用方的消息
+func WithAutoTruncate(autoTruncate bool) Option {
+	return func(c *Client) {
+		c.autoTruncate = autoTruncate
+	}
+}
+
+// 检查消息编码后的大小，超出限制时按配置截断或返回 *PayloadTooLargeError
+func (c *Client) fitPayload(deviceType DeviceType, message Message) (Message, error) {
+	platform, limit := "android", c.androidPayloadLimit
+	if deviceType == DEVICE_IOS {
+		platform, limit = "ios", c.iosPayloadLimit
+	}
+
+	size := len(message.ToJSON())
+	if limit <= 0 || size <= limit {
+		return message, nil
+	}
+
+	if c.autoTruncate {
+		if truncated, ok := truncateMessage(message, limit); ok {
+			return truncated, nil
+		}
+	}
+	return nil, &PayloadTooLargeError{Platform: platform, Size: size, Limit: limit}
+}
+
+// 截断消息正文使编码后不超过 limit 字节，Raw 消息、正文截空仍然超长时返回 false
+func truncateMessage(message Message, limit int) (Message, bool) {
+	switch m := message.(type) {
+	case *MessageAndroid:
+		if m.Raw != "" {
+			return nil, false
+		}
+		cp := *m
+		fitted := fitText(m.Content, limit, func(text string) int {
+			cp.Content = text
+			return len(cp.ToJSON())
+		})
+		return &cp, fitted
+
+	case *MessageIOS:
+		if m.Raw != "" {
+			return nil, false
+		}
+		cp := *m
+		if m.Alert != nil {
+			alert := *m.Alert
+			cp.Alert = &alert
+			fitted := fitText(m.Alert.Body, limit, func(text string) int {
+				alert.Body = text
+				return len(cp.ToJSON())
+			})
+			return &cp, fitted
+		}
+		fitted := fitText(m.AlertStr, limit, func(text string) int {
+			cp.AlertStr = text
+			return len(cp.ToJSON())
+		})
+		return &cp, fitted
+	}
+	return nil, false
+}
+
+// 二分查找能保留的最多字符数，size 把截断后的文本写回消息副本并返回编码后的大小，
+// 成功时消息副本中保留的是最终的截断结果
+func fitText(text string, limit int, size func(string) int) bool {
+	runes := []rune(text)
+	cut := func(n int) string {
+		return string(runes[:n]) + TRUNCATE_ELLIPSIS
+	}
+
+	if size(cut(0)) > limit {
+		return false
+	}
+
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if size(cut(mid)) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	size(cut(lo))
+	return true
+}
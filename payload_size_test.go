@@ -0,0 +1,65 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/panjunjie/xinge"
+)
+
+func TestPayloadTooLarge(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.RegisterToken(testTokenA)
+
+	msg := EasyMessageAndroid("title", strings.Repeat("超长内容", 500))
+	_, err := c.Checked().PushSingleDevice(context.Background(), testTokenA, msg)
+	var sizeErr *PayloadTooLargeError
+	if !errors.As(err, &sizeErr) || !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("err = %v, want *PayloadTooLargeError", err)
+	}
+	if sizeErr.Platform != "android" || sizeErr.Size != len(msg.ToJSON()) || sizeErr.Limit != ANDROID_MAX_PAYLOAD {
+		t.Errorf("err = %+v", sizeErr)
+	}
+	if len(srv.Pushes()) != 0 {
+		t.Errorf("oversized message reached the server")
+	}
+}
+
+func TestPayloadAutoTruncate(t *testing.T) {
+	c, srv := newTestClient(t, WithAutoTruncate(true), WithPayloadLimit(512, 256))
+	srv.RegisterToken(testTokenA)
+
+	content := strings.Repeat("超长内容🎉", 200)
+	msg := EasyMessageAndroid("title", content)
+	if _, err := c.Checked().PushSingleDevice(context.Background(), testTokenA, msg); err != nil {
+		t.Fatalf("PushSingleDevice: %v", err)
+	}
+	if msg.Content != content {
+		t.Errorf("caller's message was modified")
+	}
+
+	sent, err := ParseMessageAndroid([]byte(srv.Pushes()[0].Message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Pushes()[0].Message); n > 512 || n < 400 {
+		t.Errorf("truncated payload is %d bytes, want close to 512", n)
+	}
+	if !utf8.ValidString(sent.Content) || !strings.HasSuffix(sent.Content, TRUNCATE_ELLIPSIS) ||
+		!strings.HasPrefix(content, strings.TrimSuffix(sent.Content, TRUNCATE_ELLIPSIS)) {
+		t.Errorf("truncated content = %q", sent.Content)
+	}
+
+	for _, m := range []*MessageIOS{EasyMessageIOS(content, IOSENV_DEV), EasyMessageIOS("", IOSENV_DEV)} {
+		if m.AlertStr == "" {
+			m.SetApsAlert(NewApsAlert("title", content))
+		}
+		truncated, ok := TruncateMessage(m, 256)
+		if !ok || len(truncated.ToJSON()) > 256 {
+			t.Errorf("truncateMessage(ios) = %s, %v", truncated.ToJSON(), ok)
+		}
+	}
+}
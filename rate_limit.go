@@ -0,0 +1,152 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 所有 *RateLimitError 都满足 errors.Is(err, ErrRateLimited)
+var ErrRateLimited = errors.New("xinge: rate limited")
+
+// 客户端限流器拒绝请求时的错误：WithRateLimitFailFast(true) 时令牌不足，或等待令牌期间 ctx 结束
+type RateLimitError struct {
+	Endpoint   string
+	RetryAfter time.Duration
+	Err        error // 等待期间 ctx 结束时为 ctx 的错误
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("xinge: rate limit wait on %s: %v", e.Endpoint, e.Err)
+	}
+	return fmt.Sprintf("xinge: rate limited on %s, retry after %s", e.Endpoint, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// 为指定接口（RESTAPI_* 常量）设置客户端限流：每秒 qps 个请求，允许 burst 个突发请求。
+// 如 PushAllDevices 的频率限制远低于单设备推送，可单独为 RESTAPI_PUSHALLDEVICE 设置
+func WithRateLimit(uri string, qps float64, burst int) Option {
+	return func(c *Client) {
+		if c.limiters == nil {
+			c.limiters = make(map[string]*tokenBucket)
+		}
+		c.limiters[uri] = newTokenBucket(qps, burst)
+	}
+}
+
+// 为整个 access id 设置客户端限流，所有接口共享，与 WithRateLimit 同时生效
+func WithDefaultRateLimit(qps float64, burst int) Option {
+	return func(c *Client) {
+		c.defaultLimiter = newTokenBucket(qps, burst)
+	}
+}
+
+// 超出限流时是否立即返回 *RateLimitError，默认 false：阻塞等待直到可以发送或 ctx 结束
+func WithRateLimitFailFast(failFast bool) Option {
+	return func(c *Client) {
+		c.rateLimitFailFast = failFast
+	}
+}
+
+// 发送请求前依次经过全局限流和接口限流
+func (c *Client) waitRateLimit(ctx context.Context, uri string) error {
+	if c.defaultLimiter != nil {
+		if err := c.defaultLimiter.wait(ctx, uri, c.rateLimitFailFast); err != nil {
+			return err
+		}
+	}
+	if l, ok := c.limiters[uri]; ok {
+		if err := l.wait(ctx, uri, c.rateLimitFailFast); err != nil {
+			// 请求不会发出，归还已取得的全局令牌
+			if c.defaultLimiter != nil {
+				c.defaultLimiter.release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// 令牌桶限流器
+type tokenBucket struct {
+	mu     sync.Mutex
+	qps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(qps float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		qps:    qps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// 取一个令牌，令牌不足时预支并等待，failFast 时直接返回 *RateLimitError
+func (b *tokenBucket) wait(ctx context.Context, uri string, failFast bool) error {
+	if b.qps <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.qps
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return nil
+	}
+
+	delay := time.Duration((1 - b.tokens) / b.qps * float64(time.Second))
+	if failFast {
+		b.mu.Unlock()
+		return &RateLimitError{Endpoint: uri, RetryAfter: delay}
+	}
+	b.tokens--
+	b.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 放弃等待，归还预支的令牌
+		b.release()
+		return &RateLimitError{Endpoint: uri, RetryAfter: delay, Err: ctx.Err()}
+	}
+}
+
+// 归还一个令牌，用于请求最终没有发出的情况
+func (b *tokenBucket) release() {
+	if b.qps <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.mu.Unlock()
+}
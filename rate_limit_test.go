@@ -0,0 +1,84 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ret_code":0}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	msg := EasyMessageAndroid("t", "c")
+	c := NewClient(2100000000, "secret", WithBaseURL(srv.URL),
+		WithRateLimit(RESTAPI_PUSHALLDEVICE, 0.01, 1), WithRateLimitFailFast(true))
+	if _, err := c.Checked().PushAllDevices(ctx, msg); err != nil {
+		t.Fatalf("first PushAllDevices: %v", err)
+	}
+	_, err := c.Checked().PushAllDevices(ctx, msg)
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || !errors.Is(err, ErrRateLimited) || rlErr.Endpoint != RESTAPI_PUSHALLDEVICE {
+		t.Fatalf("second PushAllDevices: err = %v, want *RateLimitError", err)
+	}
+	if _, err := c.Checked().PushSingleDevice(ctx, "token", msg); err != nil {
+		t.Errorf("PushSingleDevice should not be limited: %v", err)
+	}
+
+	c = NewClient(2100000000, "secret", WithBaseURL(srv.URL), WithDefaultRateLimit(50, 1))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.Checked().QueryDeviceCount(ctx); err != nil {
+			t.Fatalf("QueryDeviceCount: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("3 calls at 50 qps took %s, want blocking", elapsed)
+	}
+}
+
+// 被接口限流拒绝或等待期间 ctx 结束的请求不消耗全局令牌
+func TestRateLimitRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ret_code":0}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	msg := EasyMessageAndroid("t", "c")
+	c := NewClient(2100000000, "secret", WithBaseURL(srv.URL), WithDefaultRateLimit(0.01, 2),
+		WithRateLimit(RESTAPI_PUSHALLDEVICE, 0.01, 1), WithRateLimitFailFast(true))
+	if _, err := c.Checked().PushAllDevices(ctx, msg); err != nil {
+		t.Fatalf("first PushAllDevices: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Checked().PushAllDevices(ctx, msg); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("PushAllDevices: err = %v, want ErrRateLimited", err)
+		}
+	}
+	if _, err := c.Checked().PushSingleDevice(ctx, "token", msg); err != nil {
+		t.Errorf("PushSingleDevice should use the global token returned by rejected calls: %v", err)
+	}
+
+	c = NewClient(2100000000, "secret", WithBaseURL(srv.URL), WithDefaultRateLimit(0.01, 2),
+		WithRateLimit(RESTAPI_PUSHALLDEVICE, 0.01, 1))
+	if _, err := c.Checked().PushAllDevices(ctx, msg); err != nil {
+		t.Fatalf("first PushAllDevices: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err := c.Checked().PushAllDevices(waitCtx, msg)
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || !errors.Is(err, context.DeadlineExceeded) || rlErr.Endpoint != RESTAPI_PUSHALLDEVICE {
+		t.Fatalf("PushAllDevices with expiring ctx: err = %v, want *RateLimitError wrapping the ctx error", err)
+	}
+	if _, err := c.Checked().PushSingleDevice(ctx, "token", msg); err != nil {
+		t.Errorf("PushSingleDevice should use the global token returned by the cancelled call: %v", err)
+	}
+}
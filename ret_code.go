@@ -0,0 +1,118 @@
+package xinge
+
+import "fmt"
+
+// 信鸽接口返回的 ret_code
+type RetCode int
+
+// 信鸽官方文档列出的 ret_code，详见 http://docs.developer.qq.com/xg/server_api/rest.html
+const (
+	RETCODE_SUCCESS              RetCode = 0   // 调用成功
+	RETCODE_PARAM_ERROR          RetCode = -1  // 参数错误（本 SDK 的本地错误也使用 -1）
+	RETCODE_TIMESTAMP_EXPIRED    RetCode = -2  // 请求时间戳不在有效期内
+	RETCODE_SIGN_ERROR           RetCode = -3  // sign 校验无效，检查 access id 和 secret key
+	RETCODE_SERVER_PARAM_ERROR   RetCode = 2   // 参数错误
+	RETCODE_ACCOUNT_TOKEN_FULL   RetCode = 7   // 账号绑定的终端数满了（10个）
+	RETCODE_TOKEN_INVALID        RetCode = 14  // 收到非法 token
+	RETCODE_SERVER_BUSY          RetCode = 15  // 信鸽逻辑服务器繁忙
+	RETCODE_OPERATION_ORDER      RetCode = 19  // 操作时序错误
+	RETCODE_AUTH_ERROR           RetCode = 20  // 鉴权错误，access id 和 access key 不匹配
+	RETCODE_TOKEN_NOT_REGISTERED RetCode = 40  // 推送的 token 没有在信鸽中注册
+	RETCODE_ACCOUNT_NOT_BOUND    RetCode = 48  // 推送的账号没有绑定 token
+	RETCODE_TAG_SERVER_BUSY      RetCode = 63  // 标签系统忙
+	RETCODE_APNS_BUSY            RetCode = 71  // APNS 服务器繁忙
+	RETCODE_MESSAGE_TOO_LONG     RetCode = 73  // 消息字符数超限
+	RETCODE_RATE_LIMITED         RetCode = 76  // 请求过于频繁，请稍后再试
+	RETCODE_LOOP_PARAM_ERROR     RetCode = 78  // 循环任务参数错误
+	RETCODE_APNS_CERT_ERROR      RetCode = 100 // APNS 证书错误
+)
+
+var retCodeText = map[RetCode]string{
+	RETCODE_SUCCESS:              "调用成功",
+	RETCODE_PARAM_ERROR:          "参数错误",
+	RETCODE_TIMESTAMP_EXPIRED:    "请求时间戳不在有效期内",
+	RETCODE_SIGN_ERROR:           "sign校验无效",
+	RETCODE_SERVER_PARAM_ERROR:   "参数错误",
+	RETCODE_ACCOUNT_TOKEN_FULL:   "账号绑定的终端数满了",
+	RETCODE_TOKEN_INVALID:        "收到非法token",
+	RETCODE_SERVER_BUSY:          "信鸽逻辑服务器繁忙",
+	RETCODE_OPERATION_ORDER:      "操作时序错误",
+	RETCODE_AUTH_ERROR:           "鉴权错误",
+	RETCODE_TOKEN_NOT_REGISTERED: "推送的token没有在信鸽中注册",
+	RETCODE_ACCOUNT_NOT_BOUND:    "推送的账号没有绑定token",
+	RETCODE_TAG_SERVER_BUSY:      "标签系统忙",
+	RETCODE_APNS_BUSY:            "APNS服务器繁忙",
+	RETCODE_MESSAGE_TOO_LONG:     "消息字符数超限",
+	RETCODE_RATE_LIMITED:         "请求过于频繁，请稍后再试",
+	RETCODE_LOOP_PARAM_ERROR:     "循环任务参数错误",
+	RETCODE_APNS_CERT_ERROR:      "APNS证书错误",
+}
+
+func (r RetCode) String() string {
+	if text, ok := retCodeText[r]; ok {
+		return text
+	}
+	return fmt.Sprintf("未知错误(%d)", int(r))
+}
+
+// 时间戳过期、服务端繁忙或限流，稍后重试可能成功
+func (r RetCode) Retryable() bool {
+	switch r {
+	case RETCODE_TIMESTAMP_EXPIRED, RETCODE_SERVER_BUSY, RETCODE_TAG_SERVER_BUSY, RETCODE_APNS_BUSY, RETCODE_RATE_LIMITED:
+		return true
+	}
+	return false
+}
+
+// access id / secret key / 证书等鉴权配置有误，重试无效，需要告警处理
+func (r RetCode) IsAuthError() bool {
+	switch r {
+	case RETCODE_SIGN_ERROR, RETCODE_AUTH_ERROR, RETCODE_APNS_CERT_ERROR:
+		return true
+	}
+	return false
+}
+
+// token 非法或未注册，可以从业务库中清理该 token
+func (r RetCode) IsInvalidToken() bool {
+	switch r {
+	case RETCODE_TOKEN_INVALID, RETCODE_TOKEN_NOT_REGISTERED:
+		return true
+	}
+	return false
+}
+
+// 返回类型化的 ret_code
+func (s XgResponse) RetCode() RetCode {
+	return RetCode(s.Code)
+}
+
+// 是否调用成功
+func (s XgResponse) Success() bool {
+	return s.Code == int(RETCODE_SUCCESS)
+}
+
+// ret_code 是否可重试，见 RetCode.Retryable
+func (s XgResponse) Retryable() bool {
+	return s.RetCode().Retryable()
+}
+
+// access id / secret key / 证书等鉴权配置有误
+func (s XgResponse) IsAuthError() bool {
+	return s.RetCode().IsAuthError()
+}
+
+// token 非法或未注册
+func (s XgResponse) IsInvalidToken() bool {
+	return s.RetCode().IsInvalidToken()
+}
+
+// 返回类型化的 ret_code
+func (e *APIError) RetCode() RetCode {
+	return RetCode(e.Code)
+}
+
+// ret_code 是否可重试，见 RetCode.Retryable
+func (e *APIError) Retryable() bool {
+	return e.RetCode().Retryable()
+}
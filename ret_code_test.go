@@ -0,0 +1,30 @@
+package xinge
+
+import "testing"
+
+func TestRetCodeClassification(t *testing.T) {
+	cases := []struct {
+		code         int
+		retryable    bool
+		authError    bool
+		invalidToken bool
+	}{
+		{0, false, false, false},
+		{-2, true, false, false},
+		{-3, false, true, false},
+		{14, false, false, true},
+		{15, true, false, false},
+		{20, false, true, false},
+		{40, false, false, true},
+		{76, true, false, false},
+		{100, false, true, false},
+		{9999, false, false, false},
+	}
+	for _, tc := range cases {
+		resp := NewRespone(tc.code, "")
+		if resp.Retryable() != tc.retryable || resp.IsAuthError() != tc.authError || resp.IsInvalidToken() != tc.invalidToken {
+			t.Errorf("code %d (%s): retryable=%v auth=%v invalidToken=%v", tc.code, resp.RetCode(),
+				resp.Retryable(), resp.IsAuthError(), resp.IsInvalidToken())
+		}
+	}
+}
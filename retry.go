@@ -0,0 +1,100 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// 请求失败时的重试策略，通过 WithRetryPolicy 配置到 Client
+type RetryPolicy struct {
+	MaxAttempts int           // 最多请求次数（包括第一次），小于等于 1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	MaxDelay    time.Duration // 单次等待时间的上限，0 表示不限制
+	Jitter      float64       // 等待时间的随机抖动比例，取值 0~1，如 0.2 表示 ±20%
+
+	// 需要重试的 ret_code，为空时使用 RetCode.Retryable 的判断
+	RetryableCodes []RetCode
+
+	// 推送类接口（如 PushAllDevices）不是幂等的，网络错误时可能已经推送成功，
+	// 重试可能导致用户收到重复消息，默认不重试，需要时显式打开
+	RetryNonIdempotent bool
+}
+
+// 默认的重试策略：最多 3 次，200ms 起指数退避，上限 5s，±20% 抖动
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// 设置请求失败时的重试策略，默认不重试
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// 会真正发出推送的接口，重试可能导致重复推送
+var nonIdempotentEndpoints = map[string]bool{
+	RESTAPI_PUSHSINGLEDEVICE:        true,
+	RESTAPI_PUSHSINGLEACCOUNT:       true,
+	RESTAPI_PUSHACCOUNTLIST:         true,
+	RESTAPI_PUSHALLDEVICE:           true,
+	RESTAPI_PUSHTAGS:                true,
+	RESTAPI_CREATEMULTIPUSH:         true,
+	RESTAPI_PUSHACCOUNTLISTMULTIPLE: true,
+	RESTAPI_PUSHDEVICELISTMULTIPLE:  true,
+}
+
+// 判断本次失败是否需要重试：ctx 已结束的不重试，网络错误和可重试的 ret_code 重试
+func (p *RetryPolicy) shouldRetry(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if len(p.RetryableCodes) == 0 {
+			return apiErr.Retryable()
+		}
+		for _, code := range p.RetryableCodes {
+			if apiErr.RetCode() == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var transErr *TransportError
+	if errors.As(err, &transErr) {
+		return transErr.Op == "post" || transErr.Op == "read response"
+	}
+	return false
+}
+
+// 第 attempt 次请求失败后的等待时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (rand.Float64()*2 - 1))
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
@@ -0,0 +1,40 @@
+package xinge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Write([]byte(`{"ret_code":15,"err_msg":"server busy"}`))
+			return
+		}
+		w.Write([]byte(`{"ret_code":0}`))
+	}))
+	defer srv.Close()
+
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Jitter: 0.5}
+	c := NewClient(2100000000, "secret", WithBaseURL(srv.URL), WithRetryPolicy(policy))
+
+	if _, err := c.Checked().QueryDeviceCount(context.Background()); err != nil || calls != 3 {
+		t.Fatalf("QueryDeviceCount: err = %v, calls = %d, want success after 3 calls", err, calls)
+	}
+
+	calls = 0
+	if _, err := c.Checked().PushAllDevices(context.Background(), EasyMessageAndroid("t", "c")); err == nil || calls != 1 {
+		t.Errorf("PushAllDevices: err = %v, calls = %d, want no retry for non-idempotent call", err, calls)
+	}
+
+	calls = 0
+	policy.RetryNonIdempotent = true
+	if _, err := c.Checked().PushAllDevices(context.Background(), EasyMessageAndroid("t", "c")); err != nil || calls != 3 {
+		t.Errorf("PushAllDevices opt-in: err = %v, calls = %d, want success after 3 calls", err, calls)
+	}
+}
@@ -0,0 +1,255 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// 推送任务的状态，与 QueryPushStatus 返回的 status 一致
+const (
+	STATUS_PENDING  PushStatus = 0 // 未处理（定时推送尚未到时间）
+	STATUS_PUSHING  PushStatus = 1 // 推送中
+	STATUS_FINISHED PushStatus = 2 // 推送完成
+	STATUS_FAILED   PushStatus = 3 // 推送失败
+
+	DEFAULT_STATUS_POLL_INTERVAL     = 2 * time.Second
+	DEFAULT_STATUS_POLL_MAX_INTERVAL = time.Minute
+	DEFAULT_STATUS_BATCH_SIZE        = 100 // 每次 QueryPushStatus 查询的 push_id 数
+)
+
+// 推送任务的进度事件
+type PushProgress struct {
+	PushId    string
+	Status    PushStatus
+	StartTime string
+	Finished  int64
+	Total     int64
+	Done      bool  // 已到达终态（STATUS_FINISHED 或 STATUS_FAILED），或遇到不可重试的错误
+	Err       error // 查询失败的原因；Done 为 false 时会继续重试
+}
+
+// StatusTracker 的可选配置项
+type TrackerOption func(*StatusTracker)
+
+// 设置轮询间隔：有进度变化时使用 interval，没有变化或查询失败时逐次加倍，最长 maxInterval
+func WithPollInterval(interval, maxInterval time.Duration) TrackerOption {
+	return func(t *StatusTracker) {
+		if interval > 0 {
+			t.interval = interval
+		}
+		if maxInterval >= t.interval {
+			t.maxInterval = maxInterval
+		}
+	}
+}
+
+// 设置每次 QueryPushStatus 查询的 push_id 数
+func WithStatusBatchSize(n int) TrackerOption {
+	return func(t *StatusTracker) {
+		if n > 0 {
+			t.batchSize = n
+		}
+	}
+}
+
+/**
+ * 推送状态跟踪器，用 QueryPushStatus 批量轮询多个推送任务，直到每个任务到达终态
+ *
+ *	tracker := client.NewStatusTracker(xinge.WithPollInterval(time.Second, 30*time.Second))
+ *	for p := range tracker.Track(ctx, pushIds) {
+ *		log.Printf("%s: %d/%d done=%v", p.PushId, p.Finished, p.Total, p.Done)
+ *	}
+ */
+type StatusTracker struct {
+	c           *Client
+	interval    time.Duration
+	maxInterval time.Duration
+	batchSize   int
+}
+
+// 实例化推送状态跟踪器，opts 见 WithPollInterval、WithStatusBatchSize
+func (c *Client) NewStatusTracker(opts ...TrackerOption) *StatusTracker {
+	t := &StatusTracker{
+		c:           c,
+		interval:    DEFAULT_STATUS_POLL_INTERVAL,
+		maxInterval: DEFAULT_STATUS_POLL_MAX_INTERVAL,
+		batchSize:   DEFAULT_STATUS_BATCH_SIZE,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.maxInterval < t.interval {
+		t.maxInterval = t.interval
+	}
+	return t
+}
+
+/**
+ * 开始跟踪推送任务，状态或进度变化时发出事件，每个任务到达终态时发出 Done 为 true 的事件
+ *
+ * @param pushIds 推送接口返回的 push_id，重复的会被忽略
+ * @return 进度事件，所有任务到达终态或 ctx 结束后关闭；调用方需要持续读取
+ */
+func (t *StatusTracker) Track(ctx context.Context, pushIds []string) <-chan PushProgress {
+	events := make(chan PushProgress)
+	go t.run(ctx, dedupe(pushIds), events)
+	return events
+}
+
+/**
+ * 等待所有推送任务到达终态
+ *
+ * @param pushIds 推送接口返回的 push_id
+ * @return 每个任务最后一次的进度；ctx 在所有任务到达终态前结束时同时返回 ctx 的错误，
+ *         有任务因不可重试的错误（push_id 不合法、鉴权失败等）结束时返回这些错误的汇总
+ */
+func (t *StatusTracker) Wait(ctx context.Context, pushIds []string) (map[string]PushProgress, error) {
+	last := make(map[string]PushProgress)
+	for p := range t.Track(ctx, pushIds) {
+		last[p.PushId] = p
+	}
+
+	// Track 只会在所有任务到达终态或 ctx 结束后关闭事件通道
+	var errs []error
+	seen := make(map[string]bool)
+	for _, id := range dedupe(pushIds) {
+		p := last[id]
+		if !p.Done {
+			return last, ctx.Err()
+		}
+		// 同一批次的任务共用一个错误，只汇总一次
+		if p.Err != nil && !seen[p.Err.Error()] {
+			seen[p.Err.Error()] = true
+			errs = append(errs, p.Err)
+		}
+	}
+	return last, errors.Join(errs...)
+}
+
+func (t *StatusTracker) run(ctx context.Context, pushIds []string, events chan<- PushProgress) {
+	defer close(events)
+
+	emit := func(p PushProgress) bool {
+		select {
+		case events <- p:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// 一个不合法的 push_id 会让 QueryPushStatus 拒绝整批，先单独结束这些任务
+	pending := make([]string, 0, len(pushIds))
+	for _, id := range pushIds {
+		if isPushId(id) {
+			pending = append(pending, id)
+			continue
+		}
+		err := newValidationError("push_id", "must be a decimal push id, got "+strconv.Quote(id))
+		if !emit(PushProgress{PushId: id, Done: true, Err: err}) {
+			return
+		}
+	}
+
+	seen := make(map[string]PushProgress, len(pending))
+	interval := t.interval
+	for len(pending) > 0 {
+		changed, failed := false, false
+		next := make([]string, 0, len(pending))
+
+		for start := 0; start < len(pending); start += t.batchSize {
+			end := start + t.batchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			batch := pending[start:end]
+
+			res, err := t.c.Checked().QueryPushStatus(ctx, batch)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				done := !retryableStatusError(err)
+				for _, id := range batch {
+					if !emit(PushProgress{PushId: id, Done: done, Err: err}) {
+						return
+					}
+				}
+				if !done {
+					failed = true
+					next = append(next, batch...)
+				}
+				continue
+			}
+
+			statuses := make(map[string]XgResultList)
+			if res.XgResult != nil {
+				for _, item := range res.XgResult.XgResultList {
+					statuses[item.PushId] = item
+				}
+			}
+			for _, id := range batch {
+				item, ok := statuses[id]
+				if !ok {
+					// 服务端暂时查不到的任务继续轮询
+					next = append(next, id)
+					continue
+				}
+
+				status := PushStatus(item.Status)
+				p := PushProgress{
+					PushId:    id,
+					Status:    status,
+					StartTime: item.StartTime,
+					Finished:  item.Finished,
+					Total:     item.Total,
+					Done:      status == STATUS_FINISHED || status == STATUS_FAILED,
+				}
+				if prev, ok := seen[id]; !ok || prev != p {
+					changed = true
+					seen[id] = p
+					if !emit(p) {
+						return
+					}
+				}
+				if !p.Done {
+					next = append(next, id)
+				}
+			}
+		}
+
+		pending = next
+		if len(pending) == 0 {
+			return
+		}
+
+		// 有进度时按初始间隔轮询，否则逐次加倍
+		if changed && !failed {
+			interval = t.interval
+		} else if interval *= 2; interval > t.maxInterval {
+			interval = t.maxInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// 网络错误和服务端繁忙时继续轮询，参数错误、鉴权失败等重试也不会成功的错误直接结束这一批
+func retryableStatusError(err error) bool {
+	if errors.Is(err, ErrInvalidParam) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return true
+}
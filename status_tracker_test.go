@@ -0,0 +1,166 @@
+package xinge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/panjunjie/xinge"
+	"github.com/panjunjie/xinge/xingetest"
+)
+
+func createTestPushes(t *testing.T, c *Client, n int) []string {
+	t.Helper()
+	ids := make([]string, n)
+	for i := range ids {
+		res, err := c.Checked().CreateMultipush(context.Background(), EasyMessageAndroid("title", "content"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = strconv.FormatInt(res.XgResult.PushId, 10)
+	}
+	return ids
+}
+
+func statusRequests(srv *xingetest.Server) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Get("push_ids") != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStatusTrackerTrack(t *testing.T) {
+	c, srv := newTestClient(t)
+	ids := createTestPushes(t, c, 3)
+	tracker := c.NewStatusTracker(WithPollInterval(5*time.Millisecond, 20*time.Millisecond), WithStatusBatchSize(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(map[string]PushProgress)
+	for p := range tracker.Track(ctx, append(ids, ids[0])) {
+		if p.Err != nil {
+			t.Fatalf("event error: %v", p.Err)
+		}
+		if p.Done {
+			done[p.PushId] = p
+			continue
+		}
+
+		// 第一次看到推送中的任务后推进它的状态
+		id, _ := strconv.ParseInt(p.PushId, 10, 64)
+		switch p.PushId {
+		case ids[0]:
+			srv.SetPushStatus(id, STATUS_FINISHED, 10, 10)
+		case ids[1]:
+			srv.SetPushStatus(id, STATUS_FAILED, 3, 10)
+		case ids[2]:
+			if p.Finished == 0 {
+				srv.SetPushStatus(id, STATUS_PUSHING, 5, 10)
+			} else {
+				srv.SetPushStatus(id, STATUS_FINISHED, 10, 10)
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		t.Fatalf("tracker did not finish: %v", ctx.Err())
+	}
+	if len(done) != 3 || done[ids[0]].Status != STATUS_FINISHED || done[ids[1]].Status != STATUS_FAILED || done[ids[2]].Finished != 10 {
+		t.Errorf("done = %+v", done)
+	}
+	// 3 个任务每轮按 2 个一批查询
+	for _, req := range srv.Requests() {
+		if raw := req.Get("push_ids"); raw != "" {
+			var list []map[string]string
+			if err := json.Unmarshal([]byte(raw), &list); err != nil || len(list) == 0 || len(list) > 2 {
+				t.Errorf("push_ids = %s", raw)
+			}
+		}
+	}
+}
+
+func TestStatusTrackerWait(t *testing.T) {
+	c, srv := newTestClient(t)
+	ids := createTestPushes(t, c, 2)
+	tracker := c.NewStatusTracker(WithPollInterval(5*time.Millisecond, 10*time.Millisecond))
+
+	for _, id := range ids {
+		pushId, _ := strconv.ParseInt(id, 10, 64)
+		srv.SetPushStatus(pushId, STATUS_FINISHED, 1, 1)
+	}
+	res, err := tracker.Wait(context.Background(), ids)
+	if err != nil || len(res) != 2 || !res[ids[1]].Done {
+		t.Fatalf("Wait = %+v, %v", res, err)
+	}
+	if n := statusRequests(srv); n != 1 {
+		t.Errorf("status requests = %d, want a single multi-id query", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stuck := createTestPushes(t, c, 1)
+	if _, err := tracker.Wait(ctx, stuck); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait on unfinished push: err = %v", err)
+	}
+
+}
+
+// 不合法的 push_id 单独结束，同一批的其余任务继续跟踪
+func TestStatusTrackerInvalidPushId(t *testing.T) {
+	c, srv := newTestClient(t)
+	ids := createTestPushes(t, c, 2)
+	for _, id := range ids {
+		pushId, _ := strconv.ParseInt(id, 10, 64)
+		srv.SetPushStatus(pushId, STATUS_FINISHED, 1, 1)
+	}
+	tracker := c.NewStatusTracker(WithPollInterval(5*time.Millisecond, 10*time.Millisecond))
+
+	res, err := tracker.Wait(context.Background(), []string{ids[0], "not-a-push-id", ids[1]})
+	if !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("Wait err = %v, want ErrInvalidParam", err)
+	}
+	if p := res["not-a-push-id"]; !p.Done || !errors.Is(p.Err, ErrInvalidParam) {
+		t.Errorf("invalid push id: %+v", p)
+	}
+	for _, id := range ids {
+		if p := res[id]; !p.Done || p.Err != nil || p.Status != STATUS_FINISHED {
+			t.Errorf("push %s: %+v", id, p)
+		}
+	}
+}
+
+// 鉴权失败等不可重试的错误直接结束跟踪，服务端繁忙时继续重试
+func TestStatusTrackerQueryErrors(t *testing.T) {
+	c, srv := newTestClient(t)
+	ids := createTestPushes(t, c, 2)
+	tracker := c.NewStatusTracker(WithPollInterval(5*time.Millisecond, 10*time.Millisecond))
+
+	// 任务一直在推送中，重试的话 Wait 会等到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.FailNext("/v2/push/get_msg_status", int(RETCODE_AUTH_ERROR), "auth error")
+	res, err := tracker.Wait(ctx, ids)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.RetCode().IsAuthError() {
+		t.Fatalf("Wait err = %v, want auth error", err)
+	}
+	if !res[ids[0]].Done || !res[ids[1]].Done {
+		t.Errorf("Wait = %+v", res)
+	}
+
+	for _, id := range ids {
+		pushId, _ := strconv.ParseInt(id, 10, 64)
+		srv.SetPushStatus(pushId, STATUS_FINISHED, 1, 1)
+	}
+	srv.FailNext("/v2/push/get_msg_status", int(RETCODE_SERVER_BUSY), "busy")
+	if res, err := tracker.Wait(context.Background(), ids); err != nil || res[ids[0]].Status != STATUS_FINISHED {
+		t.Errorf("Wait after busy = %+v, %v", res, err)
+	}
+}
@@ -1,6 +1,52 @@
 package xinge
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	ANDROID_BADGE_UNCHANGED = -1 // 角标数字不变
+	ANDROID_BADGE_INCREASE  = -2 // 角标数字加一
+)
+
+// 厂商通道的通知渠道 ID，信鸽通过厂商通道下发时原样转发
+type VendorChannels struct {
+	HuaweiChannelId string `json:"hw_ch_id,omitempty"`
+	XiaomiChannelId string `json:"xm_ch_id,omitempty"`
+	OppoChannelId   string `json:"oppo_ch_id,omitempty"`
+	VivoChannelId   string `json:"vivo_ch_id,omitempty"`
+	MeizuChannelId  string `json:"meizu_ch_id,omitempty"`
+}
+
+// 通知样式，序列化时开关参数输出为 0 或 1
 type Style struct {
+	BuilderId int
+	Ring      bool
+	Vibrate   bool
+	Clearable bool
+	NId       int
+	RingRaw   string
+	Lights    bool
+	IconType  int
+	IconRes   string
+	StyleId   int
+	SmallIcon string
+
+	// Android O 及以上的通知渠道，未指定时通知会被归入默认渠道
+	ChannelId   string
+	ChannelName string
+	VendorChannels
+
+	BadgeType  *int   // 角标数字，或 ANDROID_BADGE_UNCHANGED / ANDROID_BADGE_INCREASE
+	BigText    string // 展开后显示的长文本
+	BigPicture string // 展开后显示的大图 URL
+}
+
+// Style 在消息 JSON 中的结构
+type styleJSON struct {
 	BuilderId int    `json:"builder_id,omitempty"`
 	Ring      int    `json:"ring,omitempty"`
 	Vibrate   int    `json:"vibrate,omitempty"`
@@ -12,17 +58,25 @@ type Style struct {
 	IconRes   string `json:"icon_res,omitempty"`
 	StyleId   int    `json:"style_id,omitempty"`
 	SmallIcon string `json:"small_icon,omitempty"`
+
+	ChannelId   string `json:"n_ch_id,omitempty"`
+	ChannelName string `json:"n_ch_name,omitempty"`
+	VendorChannels
+
+	BadgeType  *int   `json:"badge_type,omitempty"`
+	BigText    string `json:"big_text,omitempty"`
+	BigPicture string `json:"big_picture,omitempty"`
 }
 
 func NewStyle(builderId int) *Style {
-	return NewStyleFull(builderId, 0, 1, 1, 0, 1, 0, 1)
+	return NewStyleFull(builderId, false, true, true, 0, true, 0, 1)
 }
 
-func NewStyleBase(builderId int, ring int, vibrate int, clearable int, nId int) *Style {
-	return NewStyleFull(builderId, ring, vibrate, clearable, nId, 1, 0, 1)
+func NewStyleBase(builderId int, ring bool, vibrate bool, clearable bool, nId int) *Style {
+	return NewStyleFull(builderId, ring, vibrate, clearable, nId, true, 0, 1)
 }
 
-func NewStyleFull(builderId int, ring int, vibrate int, clearable int, nId int, lights int, iconType int, styleId int) *Style {
+func NewStyleFull(builderId int, ring bool, vibrate bool, clearable bool, nId int, lights bool, iconType int, styleId int) *Style {
 	return &Style{
 		BuilderId: builderId,
 		Ring:      ring,
@@ -35,30 +89,181 @@ func NewStyleFull(builderId int, ring int, vibrate int, clearable int, nId int,
 	}
 }
 
+// 设置 Android O 通知渠道，渠道不存在时客户端以 name 创建
+func (s *Style) SetChannel(id, name string) {
+	s.ChannelId = id
+	s.ChannelName = name
+}
+
+// 设置各厂商通道的通知渠道 ID
+func (s *Style) SetVendorChannels(channels VendorChannels) {
+	s.VendorChannels = channels
+}
+
+// 设置角标数字，也可以是 ANDROID_BADGE_UNCHANGED 或 ANDROID_BADGE_INCREASE
+func (s *Style) SetBadgeType(badgeType int) {
+	s.BadgeType = &badgeType
+}
+
+// 设置长文本样式，与大图样式互斥
+func (s *Style) SetBigText(text string) {
+	s.BigText = text
+}
+
+// 设置大图样式，与长文本样式互斥
+func (s *Style) SetBigPicture(pictureUrl string) {
+	s.BigPicture = pictureUrl
+}
+
 func (s *Style) IsValid() bool {
-	if s.Ring < 0 || s.Ring > 1 {
-		return false
+	return s.Validate() == nil
+}
+
+// 校验样式参数：开关取值、通知渠道成对设置、角标和大图地址
+func (s *Style) Validate() error {
+	var errs ValidationErrors
+	checkSwitch(&errs, "icon_type", s.IconType)
+	checkSwitch(&errs, "style_id", s.StyleId)
+
+	if s.ChannelId == "" && s.ChannelName != "" {
+		errs.add("n_ch_id", "required when n_ch_name is set")
 	}
+	if s.ChannelId != "" && s.ChannelName == "" {
+		errs.add("n_ch_name", "required when n_ch_id is set")
+	}
+	checkChannelId(&errs, "n_ch_id", s.ChannelId)
+	checkChannelId(&errs, "hw_ch_id", s.HuaweiChannelId)
+	checkChannelId(&errs, "xm_ch_id", s.XiaomiChannelId)
+	checkChannelId(&errs, "oppo_ch_id", s.OppoChannelId)
+	checkChannelId(&errs, "vivo_ch_id", s.VivoChannelId)
+	checkChannelId(&errs, "meizu_ch_id", s.MeizuChannelId)
 
-	if s.Vibrate < 0 || s.Vibrate > 1 {
-		return false
+	if s.BadgeType != nil && *s.BadgeType < ANDROID_BADGE_INCREASE {
+		errs.add("badge_type", fmt.Sprintf("must be a badge number, %d or %d, got %d", ANDROID_BADGE_UNCHANGED, ANDROID_BADGE_INCREASE, *s.BadgeType))
 	}
 
-	if s.Clearable < 0 || s.Clearable > 1 {
-		return false
+	if s.BigText != "" && s.BigPicture != "" {
+		errs.add("big_picture", "cannot be combined with big_text")
+	}
+	if s.BigPicture != "" {
+		if u, err := url.Parse(s.BigPicture); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("big_picture", "must be an http or https URL")
+		}
 	}
+	return errs.err()
+}
 
-	if s.Lights < 0 || s.Lights > 1 {
-		return false
+// 只能取 0 或 1 的开关参数
+func checkSwitch(errs *ValidationErrors, field string, v int) {
+	if v < 0 || v > 1 {
+		errs.add(field, fmt.Sprintf("must be 0 or 1, got %d", v))
 	}
+}
+
+func (s *Style) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
 
-	if s.IconType < 0 || s.IconType > 1 {
-		return false
+func (s *Style) UnmarshalJSON(data []byte) error {
+	var w styleJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
 	}
+	if err := w.validateSwitches(); err != nil {
+		return err
+	}
+	*s = *w.style()
+	return nil
+}
 
-	if s.StyleId < 0 || s.StyleId > 1 {
-		return false
+func (s *Style) toJSON() styleJSON {
+	return styleJSON{
+		BuilderId:      s.BuilderId,
+		Ring:           boolInt(s.Ring),
+		Vibrate:        boolInt(s.Vibrate),
+		Clearable:      boolInt(s.Clearable),
+		NId:            s.NId,
+		RingRaw:        s.RingRaw,
+		Lights:         boolInt(s.Lights),
+		IconType:       s.IconType,
+		IconRes:        s.IconRes,
+		StyleId:        s.StyleId,
+		SmallIcon:      s.SmallIcon,
+		ChannelId:      s.ChannelId,
+		ChannelName:    s.ChannelName,
+		VendorChannels: s.VendorChannels,
+		BadgeType:      s.BadgeType,
+		BigText:        s.BigText,
+		BigPicture:     s.BigPicture,
 	}
+}
 
-	return true
+func (w *styleJSON) style() *Style {
+	return &Style{
+		BuilderId:      w.BuilderId,
+		Ring:           w.Ring == 1,
+		Vibrate:        w.Vibrate == 1,
+		Clearable:      w.Clearable == 1,
+		NId:            w.NId,
+		RingRaw:        w.RingRaw,
+		Lights:         w.Lights == 1,
+		IconType:       w.IconType,
+		IconRes:        w.IconRes,
+		StyleId:        w.StyleId,
+		SmallIcon:      w.SmallIcon,
+		ChannelId:      w.ChannelId,
+		ChannelName:    w.ChannelName,
+		VendorChannels: w.VendorChannels,
+		BadgeType:      w.BadgeType,
+		BigText:        w.BigText,
+		BigPicture:     w.BigPicture,
+	}
+}
+
+// 消息 JSON 中的开关参数只能是 0 或 1
+func (w *styleJSON) validateSwitches() error {
+	var errs ValidationErrors
+	checkSwitch(&errs, "ring", w.Ring)
+	checkSwitch(&errs, "vibrate", w.Vibrate)
+	checkSwitch(&errs, "clearable", w.Clearable)
+	checkSwitch(&errs, "lights", w.Lights)
+	return errs.err()
+}
+
+// 校验消息 JSON 中的样式参数
+func (w *styleJSON) validate() error {
+	var errs ValidationErrors
+	errs.addNested("", w.validateSwitches())
+	errs.addNested("", w.style().Validate())
+	return errs.err()
+}
+
+// 渠道 ID 由客户端用作 NotificationChannel 的 id，不能包含空白字符
+func checkChannelId(errs *ValidationErrors, field, id string) {
+	if strings.ContainsAny(id, " \t\r\n") {
+		errs.add(field, "must not contain whitespace")
+	}
+}
+
+// 渠道、角标和展开样式字段只在设置时输出，未设置时消息与旧版本保持一致
+func (s *Style) putExtra(jsonObj map[string]interface{}) {
+	extra := map[string]string{
+		"n_ch_id":     s.ChannelId,
+		"n_ch_name":   s.ChannelName,
+		"hw_ch_id":    s.HuaweiChannelId,
+		"xm_ch_id":    s.XiaomiChannelId,
+		"oppo_ch_id":  s.OppoChannelId,
+		"vivo_ch_id":  s.VivoChannelId,
+		"meizu_ch_id": s.MeizuChannelId,
+		"big_text":    s.BigText,
+		"big_picture": s.BigPicture,
+	}
+	for k, v := range extra {
+		if v != "" {
+			jsonObj[k] = v
+		}
+	}
+	if s.BadgeType != nil {
+		jsonObj["badge_type"] = *s.BadgeType
+	}
 }
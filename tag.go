@@ -0,0 +1,59 @@
+package xinge
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+/**
+ * 校验标签能否正确编码：非空且为合法的 UTF-8。长度和字符的限制以信鸽服务端的校验为准
+ *
+ * @param tag 标签
+ * @return 不合法时返回 *ValidationError
+ */
+func ValidateTag(tag string) error {
+	if reason := checkTag(tag); reason != "" {
+		return newValidationError("tag", reason)
+	}
+	return nil
+}
+
+// 返回标签不合法的原因，合法时返回空字符串
+func checkTag(tag string) string {
+	if tag == "" {
+		return "empty tag"
+	}
+	if !utf8.ValidString(tag) {
+		return "must be valid UTF-8"
+	}
+	return ""
+}
+
+// tag_token_list 参数，编码为 [["tag1","token1"],["tag2","token2"]]
+type tagTokenList []TagTokenPair
+
+func (l tagTokenList) MarshalJSON() ([]byte, error) {
+	pairs := make([][2]string, len(l))
+	for i, pair := range l {
+		pairs[i] = [2]string{pair.Tag, pair.Token}
+	}
+	return json.Marshal(pairs)
+}
+
+// push_ids 参数中的一项，编码为 {"push_id":"123"}
+type pushIdEntry struct {
+	PushId string `json:"push_id"`
+}
+
+// 推送接口返回的 push_id 是十进制数字
+func isPushId(pushId string) bool {
+	if pushId == "" {
+		return false
+	}
+	for _, r := range pushId {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
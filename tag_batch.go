@@ -0,0 +1,129 @@
+package xinge
+
+import (
+	"context"
+	"errors"
+)
+
+// BatchSetTag / BatchDelTag 单次最多的 tag-token 对数
+const TAG_TOKEN_LIST_MAX_SIZE = 20
+
+// 一个 tag-token 对的处理结果
+type TagPairResult struct {
+	Pair TagTokenPair
+	Err  error // nil 表示成功；token 或 tag 不合法时为 *ValidationError，未发送；请求失败时为所在批次的错误
+}
+
+// SetTags、DeleteTags 的结果，Results 与输入顺序一致
+type TagBatchReport struct {
+	Results []TagPairResult
+}
+
+// 汇总所有失败的 tag-token 对的错误
+func (r *TagBatchReport) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 处理成功的 tag-token 对
+func (r *TagBatchReport) Succeeded() []TagTokenPair {
+	pairs := make([]TagTokenPair, 0, len(r.Results))
+	for _, res := range r.Results {
+		if res.Err == nil {
+			pairs = append(pairs, res.Pair)
+		}
+	}
+	return pairs
+}
+
+// 处理失败的 tag-token 对及原因
+func (r *TagBatchReport) Failed() []TagPairResult {
+	failed := make([]TagPairResult, 0)
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+/**
+ * 批量为 token 设置标签，数量不限：不合法的 token 单独跳过，
+ * 其余按 TAG_TOKEN_LIST_MAX_SIZE 分批调用 BatchSetTag，各批次并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param tagTokenPairs 指定token对应的指定tag
+ * @return 每个 tag-token 对的处理结果，以及所有失败原因的汇总
+ */
+func (c *Client) SetTags(ctx context.Context, tagTokenPairs []TagTokenPair) (*TagBatchReport, error) {
+	return c.batchTags(ctx, tagTokenPairs, c.Checked().BatchSetTag)
+}
+
+/**
+ * 批量为 token 删除标签，数量不限：不合法的 token 单独跳过，
+ * 其余按 TAG_TOKEN_LIST_MAX_SIZE 分批调用 BatchDelTag，各批次并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param tagTokenPairs 指定token对应的指定tag
+ * @return 每个 tag-token 对的处理结果，以及所有失败原因的汇总
+ */
+func (c *Client) DeleteTags(ctx context.Context, tagTokenPairs []TagTokenPair) (*TagBatchReport, error) {
+	return c.batchTags(ctx, tagTokenPairs, c.Checked().BatchDelTag)
+}
+
+func (c *Client) batchTags(ctx context.Context, tagTokenPairs []TagTokenPair, send func(context.Context, []TagTokenPair) (*XgResponse, error)) (*TagBatchReport, error) {
+	report := &TagBatchReport{Results: make([]TagPairResult, len(tagTokenPairs))}
+
+	// 合法的 tag-token 对在 Results 中的下标
+	valid := make([]int, 0, len(tagTokenPairs))
+	for i, pair := range tagTokenPairs {
+		report.Results[i].Pair = pair
+		if err := c.validateTagTokenPair(pair); err != nil {
+			report.Results[i].Err = err
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	chunks := make([][]int, 0, (len(valid)+TAG_TOKEN_LIST_MAX_SIZE-1)/TAG_TOKEN_LIST_MAX_SIZE)
+	for start := 0; start < len(valid); start += TAG_TOKEN_LIST_MAX_SIZE {
+		end := start + TAG_TOKEN_LIST_MAX_SIZE
+		if end > len(valid) {
+			end = len(valid)
+		}
+		chunks = append(chunks, valid[start:end])
+	}
+
+	// 各批次写入 Results 中互不重叠的下标，无需加锁
+	setErr := func(chunk []int, err error) {
+		for _, i := range chunk {
+			report.Results[i].Err = err
+		}
+	}
+	c.runBounded(ctx, len(chunks), func(n int) {
+		pairs := make([]TagTokenPair, len(chunks[n]))
+		for j, i := range chunks[n] {
+			pairs[j] = tagTokenPairs[i]
+		}
+		_, err := send(ctx, pairs)
+		setErr(chunks[n], err)
+	}, func(n int, err error) {
+		setErr(chunks[n], err)
+	})
+
+	return report, report.Err()
+}
+
+// 校验单个 tag-token 对
+func (c *Client) validateTagTokenPair(pair TagTokenPair) error {
+	if err := ValidateTag(pair.Tag); err != nil {
+		return err
+	}
+	if !c.validateToken(pair.Token) {
+		return newValidationError("token", "invalid token "+pair.Token)
+	}
+	return nil
+}
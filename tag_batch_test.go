@@ -0,0 +1,45 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+)
+
+func TestSetTagsChunked(t *testing.T) {
+	c, srv := newTestClient(t, WithBatchConcurrency(3))
+
+	pairs := make([]TagTokenPair, 0, 55)
+	for i := 0; i < 53; i++ {
+		pairs = append(pairs, TagTokenPair{Tag: fmt.Sprintf("tag%d", i%4), Token: fmt.Sprintf("%040d", i)})
+	}
+	pairs = append(pairs, TagTokenPair{Tag: "tag0", Token: "short"}, TagTokenPair{Tag: "", Token: testTokenA})
+
+	report, err := c.SetTags(context.Background(), pairs)
+	if !errors.Is(err, ErrInvalidParam) {
+		t.Fatalf("SetTags err = %v, want ErrInvalidParam for the invalid pairs", err)
+	}
+	if len(report.Results) != 55 || len(report.Succeeded()) != 53 || len(report.Failed()) != 2 {
+		t.Fatalf("succeeded %d, failed %d", len(report.Succeeded()), len(report.Failed()))
+	}
+	if failed := report.Failed(); failed[0].Pair.Token != "short" || failed[1].Pair.Tag != "" {
+		t.Errorf("failed = %+v", failed)
+	}
+	if got := len(srv.TokenTags(fmt.Sprintf("%040d", 52))); got != 1 {
+		t.Errorf("last token tags = %d", got)
+	}
+
+	srv.FailNext("/v2/tags/batch_del", 15, "server busy")
+	report, err = c.DeleteTags(context.Background(), pairs[:40])
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || len(report.Failed()) != TAG_TOKEN_LIST_MAX_SIZE || len(report.Succeeded()) != 20 {
+		t.Errorf("DeleteTags err = %v, failed %d", err, len(report.Failed()))
+	}
+
+	if res := c.BatchSetTag(pairs[:TAG_TOKEN_LIST_MAX_SIZE+1]); res.Code != -1 {
+		t.Errorf("BatchSetTag accepted %d pairs: %+v", TAG_TOKEN_LIST_MAX_SIZE+1, res)
+	}
+}
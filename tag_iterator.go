@@ -0,0 +1,108 @@
+package xinge
+
+import "context"
+
+// QueryTags 单次最多返回的标签数，也是 AllTags 默认的分页大小
+const TAG_PAGE_MAX_SIZE = 100
+
+// AllTags 的可选配置项
+type TagIteratorOption func(*TagIterator)
+
+// 设置每次调用 QueryTags 获取的标签数，取值 1 ~ TAG_PAGE_MAX_SIZE，超出范围时使用 TAG_PAGE_MAX_SIZE
+func WithTagPageSize(pageSize int64) TagIteratorOption {
+	return func(it *TagIterator) {
+		if pageSize > 0 && pageSize <= TAG_PAGE_MAX_SIZE {
+			it.pageSize = pageSize
+		}
+	}
+}
+
+/**
+ * 应用所有标签的迭代器，按需分页调用 QueryTags，取到 total 个标签或某一页为空时结束
+ *
+ *	it := client.AllTags(ctx)
+ *	for it.Next() {
+ *		fmt.Println(it.Tag())
+ *	}
+ *	if err := it.Err(); err != nil {
+ *		...
+ *	}
+ */
+type TagIterator struct {
+	c        *Client
+	ctx      context.Context
+	pageSize int64
+
+	start int64 // 下一页的起始位置
+	total int64 // 第一页返回前为 -1
+	page  []string
+	pos   int
+	tag   string
+	done  bool
+	err   error
+}
+
+// 返回应用所有标签的迭代器，第一次调用 Next 时才发起请求
+func (c *Client) AllTags(ctx context.Context, opts ...TagIteratorOption) *TagIterator {
+	it := &TagIterator{
+		c:        c,
+		ctx:      ctx,
+		pageSize: TAG_PAGE_MAX_SIZE,
+		total:    -1,
+	}
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
+
+// 移动到下一个标签，没有更多标签或出错时返回 false，出错原因见 Err
+func (it *TagIterator) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil || (it.total >= 0 && it.start >= it.total) {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.tag = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// 请求下一页
+func (it *TagIterator) fetch() {
+	res, err := it.c.Checked().QueryTags(it.ctx, it.start, it.pageSize)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.page, it.pos = nil, 0
+	it.total = 0
+	if res.XgResult != nil {
+		it.page = res.XgResult.Tags
+		it.total = res.XgResult.Total
+	}
+	it.start += int64(len(it.page))
+
+	// 空页说明标签在分页过程中被删除，不再继续请求
+	if len(it.page) == 0 {
+		it.done = true
+	}
+}
+
+// 当前标签，在 Next 返回 true 之后调用
+func (it *TagIterator) Tag() string {
+	return it.tag
+}
+
+// 迭代过程中的错误，Next 返回 false 后调用
+func (it *TagIterator) Err() error {
+	return it.err
+}
+
+// 服务端返回的标签总数，第一页返回前为 -1
+func (it *TagIterator) Total() int64 {
+	return it.total
+}
@@ -0,0 +1,59 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+)
+
+func TestAllTags(t *testing.T) {
+	c, srv := newTestClient(t)
+
+	want := make([]string, 0, 250)
+	pairs := make([]TagTokenPair, 0, 250)
+	for i := 0; i < 250; i++ {
+		tag := fmt.Sprintf("tag%03d", i)
+		want = append(want, tag)
+		pairs = append(pairs, TagTokenPair{Tag: tag, Token: testTokenA})
+	}
+	sort.Strings(want)
+	if _, err := c.SetTags(context.Background(), pairs); err != nil {
+		t.Fatal(err)
+	}
+	before := len(srv.Requests())
+
+	it := c.AllTags(context.Background(), WithTagPageSize(40))
+	got := make([]string, 0)
+	for it.Next() {
+		got = append(got, it.Tag())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) || it.Total() != 250 {
+		t.Errorf("got %d tags, total %d", len(got), it.Total())
+	}
+	if requests := len(srv.Requests()) - before; requests != 7 {
+		t.Errorf("requests = %d, want 7", requests)
+	}
+
+	srv.FailNext("/v2/tags/query_app_tags", 15, "server busy")
+	it = c.AllTags(context.Background())
+	if it.Next() {
+		t.Fatalf("Next succeeded after failure: %q", it.Tag())
+	}
+	var apiErr *APIError
+	if !errors.As(it.Err(), &apiErr) || apiErr.Code != 15 {
+		t.Errorf("Err = %v", it.Err())
+	}
+
+	c2, _ := newTestClient(t)
+	if it := c2.AllTags(context.Background()); it.Next() || it.Err() != nil || it.Total() != 0 {
+		t.Errorf("empty app: Next = true, Err = %v", it.Err())
+	}
+}
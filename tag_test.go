@@ -0,0 +1,118 @@
+package xinge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+)
+
+// 记录最后一次请求的表单并返回成功响应
+type captureTransport struct {
+	form url.Values
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if t.form, err = url.ParseQuery(string(body)); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"ret_code":0}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestValidateTag(t *testing.T) {
+	cases := map[string]bool{
+		"vip":                       true,
+		"城市_北京":                     true,
+		`a"b\c`:                     true,
+		"":                          false,
+		"has space":                 true,
+		"\x00":                      true,
+		"\xff":                      false,
+		strings.Repeat("t", 51):     true,
+		strings.Repeat("标", 16)[1:]: false,
+	}
+	for tag, valid := range cases {
+		err := ValidateTag(tag)
+		if (err == nil) != valid {
+			t.Errorf("ValidateTag(%q) = %v, want valid %v", tag, err, valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidParam) {
+			t.Errorf("ValidateTag(%q) = %v, want ErrInvalidParam", tag, err)
+		}
+	}
+}
+
+func FuzzBatchSetTagEncoding(f *testing.F) {
+	for _, seed := range []string{"vip", `a"b`, `a\b`, `x"],["evil","` + testTokenB, "标签", "a b", "</script>", ""} {
+		f.Add(seed)
+	}
+
+	transport := &captureTransport{}
+	c := NewClient(testAccessId, testSecretKey, WithTransport(transport))
+	f.Fuzz(func(t *testing.T, tag string) {
+		transport.form = nil
+		_, err := c.Checked().BatchSetTag(context.Background(), []TagTokenPair{{Tag: tag, Token: testTokenA}})
+		if ValidateTag(tag) != nil {
+			if !errors.Is(err, ErrInvalidParam) || transport.form != nil {
+				t.Fatalf("invalid tag %q: err = %v, sent = %v", tag, err, transport.form != nil)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("tag %q: %v", tag, err)
+		}
+
+		var pairs [][]string
+		if err := json.Unmarshal([]byte(transport.form.Get("tag_token_list")), &pairs); err != nil {
+			t.Fatalf("tag %q: tag_token_list %q is not valid JSON: %v", tag, transport.form.Get("tag_token_list"), err)
+		}
+		if len(pairs) != 1 || len(pairs[0]) != 2 || pairs[0][0] != tag || pairs[0][1] != testTokenA {
+			t.Fatalf("tag %q: decoded %q", tag, pairs)
+		}
+	})
+}
+
+func FuzzQueryPushStatusEncoding(f *testing.F) {
+	for _, seed := range []string{"12345", "007", `1"},{"push_id":"2`, `\`, "\x00", ""} {
+		f.Add(seed)
+	}
+
+	transport := &captureTransport{}
+	c := NewClient(testAccessId, testSecretKey, WithTransport(transport))
+	f.Fuzz(func(t *testing.T, pushId string) {
+		transport.form = nil
+		_, err := c.Checked().QueryPushStatus(context.Background(), []string{pushId, "1"})
+		if numeric := pushId != "" && strings.Trim(pushId, "0123456789") == ""; !numeric {
+			if !errors.Is(err, ErrInvalidParam) || transport.form != nil {
+				t.Fatalf("invalid push id %q: err = %v, sent = %v", pushId, err, transport.form != nil)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("push id %q: %v", pushId, err)
+		}
+
+		var ids []map[string]string
+		if err := json.Unmarshal([]byte(transport.form.Get("push_ids")), &ids); err != nil {
+			t.Fatalf("push id %q: push_ids is not valid JSON: %v", pushId, err)
+		}
+		if len(ids) != 2 || len(ids[0]) != 1 || ids[0]["push_id"] != pushId || ids[1]["push_id"] != "1" {
+			t.Fatalf("push id %q: decoded %q", pushId, ids)
+		}
+	})
+}
@@ -0,0 +1,430 @@
+package xinge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// 模板中 {{nickname}} 形式的简写占位符，渲染前改写为 text/template 的 {{.nickname}}
+var shortPlaceholder = regexp.MustCompile(`\{\{(-?\s*)([A-Za-z_][A-Za-z0-9_]*)(\s*-?)\}\}`)
+
+// text/template 中可以单独出现在 {{ }} 里的关键字，不能改写
+var templateKeywords = map[string]bool{
+	"if": true, "range": true, "with": true, "define": true, "template": true, "block": true,
+	"end": true, "else": true, "break": true, "continue": true, "nil": true, "true": true, "false": true,
+}
+
+/**
+ * 多语言消息模板，按 locale 保存 MessageAndroid / MessageIOS 模板，
+ * 标题、正文、alert 和 Custom 中的字符串值按 text/template 语法渲染，
+ * 占位符可以写成 {{nickname}} 或 {{.nickname}}，数据中缺少的变量会导致渲染失败
+ */
+type MessageTemplate struct {
+	DefaultLocale string
+
+	android map[string]*MessageAndroid
+	ios     map[string]*MessageIOS
+	parsed  map[string]*template.Template
+}
+
+// 实例化消息模板，找不到接收者 locale 对应的模板时使用 defaultLocale
+func NewMessageTemplate(defaultLocale string) *MessageTemplate {
+	return &MessageTemplate{
+		DefaultLocale: defaultLocale,
+		android:       make(map[string]*MessageAndroid),
+		ios:           make(map[string]*MessageIOS),
+		parsed:        make(map[string]*template.Template),
+	}
+}
+
+// 添加某个 locale 的 Android 消息模板，模板语法错误时返回 *ValidationError
+func (t *MessageTemplate) AddAndroid(locale string, message *MessageAndroid) error {
+	if err := t.compile(message.Title, message.Content); err != nil {
+		return err
+	}
+	if err := t.compileCustom(message.Custom); err != nil {
+		return err
+	}
+	t.android[locale] = message
+	return nil
+}
+
+// 添加某个 locale 的 iOS 消息模板，模板语法错误时返回 *ValidationError
+func (t *MessageTemplate) AddIOS(locale string, message *MessageIOS) error {
+	if err := t.compile(message.AlertStr); err != nil {
+		return err
+	}
+	if message.Alert != nil {
+		if err := t.compile(message.Alert.Title, message.Alert.Subtitle, message.Alert.Body); err != nil {
+			return err
+		}
+	}
+	if err := t.compileCustom(message.Custom); err != nil {
+		return err
+	}
+	t.ios[locale] = message
+	return nil
+}
+
+// 按 locale 渲染 Android 消息，返回模板的副本
+func (t *MessageTemplate) RenderAndroid(locale string, data map[string]interface{}) (*MessageAndroid, error) {
+	tpl, ok := t.android[t.resolveLocale(locale, func(l string) bool { return t.android[l] != nil })]
+	if !ok {
+		return nil, newValidationError("locale", fmt.Sprintf("no android template for %q", locale))
+	}
+
+	msg := cloneMessageAndroid(tpl)
+	var err error
+	if msg.Title, err = t.execute(tpl.Title, data); err != nil {
+		return nil, err
+	}
+	if msg.Content, err = t.execute(tpl.Content, data); err != nil {
+		return nil, err
+	}
+	if msg.Custom, err = t.executeCustom(tpl.Custom, data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// 按 locale 渲染 iOS 消息，返回模板的副本
+func (t *MessageTemplate) RenderIOS(locale string, data map[string]interface{}) (*MessageIOS, error) {
+	tpl, ok := t.ios[t.resolveLocale(locale, func(l string) bool { return t.ios[l] != nil })]
+	if !ok {
+		return nil, newValidationError("locale", fmt.Sprintf("no ios template for %q", locale))
+	}
+
+	msg := cloneMessageIOS(tpl)
+	var err error
+	if msg.AlertStr, err = t.execute(tpl.AlertStr, data); err != nil {
+		return nil, err
+	}
+	if msg.Alert != nil {
+		alert := msg.Alert
+		if alert.Title, err = t.execute(tpl.Alert.Title, data); err != nil {
+			return nil, err
+		}
+		if alert.Subtitle, err = t.execute(tpl.Alert.Subtitle, data); err != nil {
+			return nil, err
+		}
+		if alert.Body, err = t.execute(tpl.Alert.Body, data); err != nil {
+			return nil, err
+		}
+	}
+	if msg.Custom, err = t.executeCustom(tpl.Custom, data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// 渲染结果不能与模板共用指针、切片和 map，否则修改一个渲染结果会影响模板和其他渲染结果
+func cloneMessageAndroid(tpl *MessageAndroid) *MessageAndroid {
+	msg := *tpl
+	msg.AcceptTime = cloneAcceptTime(tpl.AcceptTime)
+	if tpl.Style != nil {
+		style := *tpl.Style
+		if tpl.Style.BadgeType != nil {
+			style.BadgeType = intPtr(*tpl.Style.BadgeType)
+		}
+		msg.Style = &style
+	}
+	if tpl.ClickAction != nil {
+		action := *tpl.ClickAction
+		if tpl.ClickAction.Browser != nil {
+			browser := *tpl.ClickAction.Browser
+			action.Browser = &browser
+		}
+		if tpl.ClickAction.AtyAttr != nil {
+			atyAttr := *tpl.ClickAction.AtyAttr
+			action.AtyAttr = &atyAttr
+		}
+		msg.ClickAction = &action
+	}
+	return &msg
+}
+
+func cloneMessageIOS(tpl *MessageIOS) *MessageIOS {
+	msg := *tpl
+	msg.AcceptTime = cloneAcceptTime(tpl.AcceptTime)
+	msg.AlertJo = append([]string(nil), tpl.AlertJo...)
+	if tpl.Badge != nil {
+		msg.Badge = intPtr(*tpl.Badge)
+	}
+	if tpl.Alert != nil {
+		alert := *tpl.Alert
+		alert.TitleLocArgs = append([]string(nil), tpl.Alert.TitleLocArgs...)
+		alert.SubtitleLocArgs = append([]string(nil), tpl.Alert.SubtitleLocArgs...)
+		alert.LocArgs = append([]string(nil), tpl.Alert.LocArgs...)
+		msg.Alert = &alert
+	}
+	if tpl.CriticalSound != nil {
+		sound := *tpl.CriticalSound
+		msg.CriticalSound = &sound
+	}
+	if tpl.RelevanceScore != nil {
+		score := *tpl.RelevanceScore
+		msg.RelevanceScore = &score
+	}
+	return &msg
+}
+
+func cloneAcceptTime(acceptTime []TimeInterval) []TimeInterval {
+	if acceptTime == nil {
+		return nil
+	}
+	cp := make([]TimeInterval, len(acceptTime))
+	for i, interval := range acceptTime {
+		if interval.StartTime != nil {
+			start := *interval.StartTime
+			cp[i].StartTime = &start
+		}
+		if interval.EndTime != nil {
+			end := *interval.EndTime
+			cp[i].EndTime = &end
+		}
+	}
+	return cp
+}
+
+// 复制自定义参数中嵌套的 map 和数组
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		cp := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			cp[k] = cloneValue(item)
+		}
+		return cp
+	case []interface{}:
+		cp := make([]interface{}, len(v))
+		for i, item := range v {
+			cp[i] = cloneValue(item)
+		}
+		return cp
+	}
+	return v
+}
+
+// 按 Client 的平台渲染消息
+func (t *MessageTemplate) render(c *Client, locale string, data map[string]interface{}) (Message, error) {
+	if c.accessId >= IOS_MIN_ID {
+		return t.RenderIOS(locale, data)
+	}
+	return t.RenderAndroid(locale, data)
+}
+
+// 依次尝试 zh-CN、zh、DefaultLocale
+func (t *MessageTemplate) resolveLocale(locale string, exists func(string) bool) string {
+	if exists(locale) {
+		return locale
+	}
+	if i := strings.IndexAny(locale, "-_"); i > 0 && exists(locale[:i]) {
+		return locale[:i]
+	}
+	return t.DefaultLocale
+}
+
+func (t *MessageTemplate) compile(sources ...string) error {
+	for _, src := range sources {
+		if _, ok := t.parsed[src]; ok || !strings.Contains(src, "{{") {
+			continue
+		}
+		tpl, err := parseTemplate(src)
+		if err != nil {
+			return err
+		}
+		t.parsed[src] = tpl
+	}
+	return nil
+}
+
+func (t *MessageTemplate) compileCustom(custom map[string]interface{}) error {
+	for _, v := range custom {
+		if s, ok := v.(string); ok {
+			if err := t.compile(s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseTemplate(src string) (*template.Template, error) {
+	expanded := shortPlaceholder.ReplaceAllStringFunc(src, func(m string) string {
+		parts := shortPlaceholder.FindStringSubmatch(m)
+		if templateKeywords[parts[2]] {
+			return m
+		}
+		return "{{" + parts[1] + "." + parts[2] + parts[3] + "}}"
+	})
+
+	tpl, err := template.New("message").Option("missingkey=error").Parse(expanded)
+	if err != nil {
+		return nil, newValidationError("template", err.Error())
+	}
+	return tpl, nil
+}
+
+func (t *MessageTemplate) execute(src string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(src, "{{") {
+		return src, nil
+	}
+
+	tpl, ok := t.parsed[src]
+	if !ok {
+		// 模板消息在 Add 之后被修改过
+		var err error
+		if tpl, err = parseTemplate(src); err != nil {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", newValidationError("template", err.Error())
+	}
+	return buf.String(), nil
+}
+
+func (t *MessageTemplate) executeCustom(custom map[string]interface{}, data map[string]interface{}) (map[string]interface{}, error) {
+	if custom == nil {
+		return nil, nil
+	}
+	rendered := make(map[string]interface{}, len(custom))
+	for k, v := range custom {
+		if s, ok := v.(string); ok {
+			var err error
+			if v, err = t.execute(s, data); err != nil {
+				return nil, err
+			}
+		}
+		rendered[k] = cloneValue(v)
+	}
+	return rendered, nil
+}
+
+// 模板推送的接收者，Account 用于按账号推送，Token 用于按设备推送
+type TemplateRecipient struct {
+	Account string
+	Token   string
+	Locale  string
+	Data    map[string]interface{}
+}
+
+// 渲染结果相同的一组接收者，共用一次推送
+type TemplateGroup struct {
+	Locale     string
+	Message    Message
+	Recipients []string
+	Response   *XgResponse
+	Err        error
+}
+
+// 模板推送的结果，每组渲染结果相同的接收者对应一个 TemplateGroup
+type TemplatePushResult struct {
+	Groups []*TemplateGroup
+}
+
+// 按渲染后的消息内容给接收者分组，渲染失败时返回出错的接收者
+func (t *MessageTemplate) group(c *Client, recipients []TemplateRecipient, target func(TemplateRecipient) string) ([]*TemplateGroup, error) {
+	groups := make([]*TemplateGroup, 0)
+	byMessage := make(map[string]*TemplateGroup)
+	for i, r := range recipients {
+		msg, err := t.render(c, r.Locale, r.Data)
+		if err != nil {
+			var valErr *ValidationError
+			if errors.As(err, &valErr) {
+				return nil, newValidationError(fmt.Sprintf("recipients[%d].%s", i, valErr.Field), valErr.Reason)
+			}
+			return nil, err
+		}
+
+		key := msg.ToJSON()
+		g, ok := byMessage[key]
+		if !ok {
+			g = &TemplateGroup{Locale: r.Locale, Message: msg}
+			byMessage[key] = g
+			groups = append(groups, g)
+		}
+		g.Recipients = append(g.Recipients, target(r))
+	}
+	return groups, nil
+}
+
+// 汇总各组的错误
+func (r *TemplatePushResult) Err() error {
+	var errs []error
+	for _, g := range r.Groups {
+		if g.Err != nil {
+			errs = append(errs, g.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/**
+ * 用模板给多个账号推送，每个账号按自己的 locale 和数据渲染消息，
+ * 渲染结果相同的账号合并成一次 PushAccountList 调用
+ * 各组并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param tmpl 消息模板
+ * @param recipients 接收者，使用其中的 Account、Locale、Data
+ * @return 每组的推送结果；任一接收者渲染失败时不发送任何推送并返回错误
+ */
+func (c *Client) PushAccountListTemplate(ctx context.Context, tmpl *MessageTemplate, recipients []TemplateRecipient) (*TemplatePushResult, error) {
+	groups, err := tmpl.group(c, recipients, func(r TemplateRecipient) string { return r.Account })
+	if err != nil {
+		return nil, err
+	}
+
+	c.sendGroups(ctx, groups, func(g *TemplateGroup) {
+		g.Response, g.Err = c.Checked().PushAccountList(ctx, g.Recipients, g.Message)
+	})
+	res := &TemplatePushResult{Groups: groups}
+	return res, res.Err()
+}
+
+/**
+ * 用模板给多个设备推送，每个设备按自己的 locale 和数据渲染消息，
+ * 渲染结果相同的设备共用一个 CreateMultipush 任务，再通过 PushDeviceListMultiple 添加设备
+ * 各组并发发送，并发数见 WithBatchConcurrency
+ *
+ * @param tmpl 消息模板
+ * @param recipients 接收者，使用其中的 Token、Locale、Data
+ * @return 每组的推送结果；任一接收者渲染失败时不发送任何推送并返回错误
+ */
+func (c *Client) PushDeviceListTemplate(ctx context.Context, tmpl *MessageTemplate, recipients []TemplateRecipient) (*TemplatePushResult, error) {
+	groups, err := tmpl.group(c, recipients, func(r TemplateRecipient) string { return r.Token })
+	if err != nil {
+		return nil, err
+	}
+
+	c.sendGroups(ctx, groups, func(g *TemplateGroup) {
+		created, err := c.Checked().CreateMultipush(ctx, g.Message)
+		if err != nil {
+			g.Response, g.Err = created, err
+			return
+		}
+		if created.XgResult == nil || created.XgResult.PushId <= 0 {
+			g.Response, g.Err = created, &APIError{Code: created.Code, Msg: "create multipush returned no push_id"}
+			return
+		}
+		g.Response, g.Err = c.Checked().PushDeviceListMultiple(ctx, created.XgResult.PushId, g.Recipients)
+	})
+	res := &TemplatePushResult{Groups: groups}
+	return res, res.Err()
+}
+
+// 以 Client 配置的并发数发送各组
+func (c *Client) sendGroups(ctx context.Context, groups []*TemplateGroup, send func(g *TemplateGroup)) {
+	c.runBounded(ctx, len(groups), func(i int) {
+		send(groups[i])
+	}, func(i int, err error) {
+		groups[i].Err = err
+	})
+}
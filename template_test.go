@@ -0,0 +1,167 @@
+package xinge_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/panjunjie/xinge"
+)
+
+func newTestTemplate(t *testing.T) *MessageTemplate {
+	tmpl := NewMessageTemplate("en")
+	if err := tmpl.AddAndroid("en", EasyMessageAndroid("Hi {{nickname}}", "You have {{.count}} new messages")); err != nil {
+		t.Fatal(err)
+	}
+	zh := EasyMessageAndroid("{{nickname}}，你好", "你有 {{count}} 条新消息")
+	zh.SetCustom(map[string]interface{}{"url": "app://inbox?user={{nickname}}", "n": 1})
+	if err := tmpl.AddAndroid("zh", zh); err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
+func TestMessageTemplateRender(t *testing.T) {
+	tmpl := newTestTemplate(t)
+
+	msg, err := tmpl.RenderAndroid("zh-CN", map[string]interface{}{"nickname": "小明", "count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Title != "小明，你好" || msg.Content != "你有 3 条新消息" || msg.Custom["url"] != "app://inbox?user=小明" || msg.Custom["n"] != 1 {
+		t.Errorf("zh-CN render = %+v", msg)
+	}
+
+	msg, err = tmpl.RenderAndroid("fr", map[string]interface{}{"nickname": "Tom", "count": 1})
+	if err != nil || msg.Title != "Hi Tom" {
+		t.Errorf("fallback render = %+v, %v", msg, err)
+	}
+
+	if _, err := tmpl.RenderAndroid("en", map[string]interface{}{"nickname": "Tom"}); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("missing variable: err = %v", err)
+	}
+	if err := tmpl.AddAndroid("de", EasyMessageAndroid("{{nickname", "")); err == nil {
+		t.Errorf("AddAndroid accepted invalid template")
+	}
+
+	ios := NewMessageTemplate("en")
+	iosMsg := EasyMessageIOS("", IOSENV_DEV)
+	iosMsg.SetApsAlert(NewApsAlert("Hi {{nickname}}", "{{count}} new"))
+	ios.AddIOS("en", iosMsg)
+	rendered, err := ios.RenderIOS("en", map[string]interface{}{"nickname": "Tom", "count": 2})
+	if err != nil || rendered.Alert.Title != "Hi Tom" || rendered.Alert.Body != "2 new" || iosMsg.Alert.Title != "Hi {{nickname}}" {
+		t.Errorf("ios render = %+v, %v", rendered.Alert, err)
+	}
+}
+
+// 修改一个渲染结果不影响模板和其他渲染结果
+func TestMessageTemplateRenderCopies(t *testing.T) {
+	tpl := EasyMessageAndroid("Hi {{nickname}}", "content")
+	tpl.SetCustom(map[string]interface{}{"meta": map[string]interface{}{"from": "system"}})
+	tpl.AddAcceptTime(TimeInterval{StartTime: &TimePart{8, 0}, EndTime: &TimePart{22, 0}})
+	tmpl := NewMessageTemplate("en")
+	if err := tmpl.AddAndroid("en", tpl); err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{"nickname": "Tom"}
+	first, _ := tmpl.RenderAndroid("en", data)
+	want, tplJSON := first.ToJSON(), tpl.ToJSON()
+	first.Style.Ring = true
+	first.Style.SetBadgeType(3)
+	first.ClickAction.Activity = "com.example.Other"
+	first.ClickAction.Browser.Url = "https://example.com"
+	first.Custom["meta"].(map[string]interface{})["from"] = "user"
+	first.AcceptTime[0].StartTime.Hour = 0
+
+	second, err := tmpl.RenderAndroid("en", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ToJSON() != want || tpl.ToJSON() != tplJSON {
+		t.Errorf("render shares state with the template:\nsecond %s\ntmpl   %s", second.ToJSON(), tpl.ToJSON())
+	}
+
+	iosTpl := EasyMessageIOS("", IOSENV_DEV)
+	iosTpl.SetApsAlert(&ApsAlert{Title: "Hi {{nickname}}", LocKey: "GREETING", LocArgs: []string{"x"}})
+	iosTpl.SetCriticalSound(NewCriticalSound("alarm.caf", 0.5))
+	ios := NewMessageTemplate("en")
+	if err := ios.AddIOS("en", iosTpl); err != nil {
+		t.Fatal(err)
+	}
+	iosFirst, _ := ios.RenderIOS("en", data)
+	iosFirst.Alert.LocArgs[0] = "y"
+	*iosFirst.Badge = 9
+	iosFirst.CriticalSound.Volume = 1
+	if iosSecond, _ := ios.RenderIOS("en", data); iosSecond.Alert.LocArgs[0] != "x" || *iosSecond.Badge != 1 || iosSecond.CriticalSound.Volume != 0.5 {
+		t.Errorf("ios render shares state with the template: %s", iosSecond.ToJSON())
+	}
+}
+
+func TestPushTemplate(t *testing.T) {
+	c, srv := newTestClient(t)
+	tmpl := newTestTemplate(t)
+	recipients := []TemplateRecipient{
+		{Account: "a1", Token: testTokenA, Locale: "zh-CN", Data: map[string]interface{}{"nickname": "小明", "count": 1}},
+		{Account: "a2", Token: testTokenB, Locale: "en", Data: map[string]interface{}{"nickname": "Tom", "count": 2}},
+		{Account: "a3", Token: "c", Locale: "zh", Data: map[string]interface{}{"nickname": "小明", "count": 1}},
+	}
+
+	res, err := c.PushAccountListTemplate(context.Background(), tmpl, recipients)
+	if err != nil {
+		t.Fatalf("PushAccountListTemplate: %v", err)
+	}
+	if len(res.Groups) != 2 || len(res.Groups[0].Recipients) != 2 || res.Groups[1].Recipients[0] != "a2" {
+		t.Fatalf("groups = %+v", res.Groups)
+	}
+
+	res, err = c.PushDeviceListTemplate(context.Background(), tmpl, recipients)
+	if err != nil || len(res.Groups) != 2 {
+		t.Fatalf("PushDeviceListTemplate: %+v, %v", res, err)
+	}
+
+	// 各组并发发送，推送的顺序不固定
+	pushes := srv.Pushes()
+	merged := 0
+	for _, p := range pushes[2:] {
+		if reflect.DeepEqual(p.Targets, []string{testTokenA, "c"}) {
+			merged++
+		}
+	}
+	if len(pushes) != 4 || merged != 1 {
+		t.Errorf("server pushes = %+v", pushes)
+	}
+
+	recipients[1].Data = nil
+	if _, err := c.PushAccountListTemplate(context.Background(), tmpl, recipients); err == nil || len(srv.Pushes()) != 4 {
+		t.Errorf("render failure should abort before sending: %v", err)
+	}
+}
+
+// 每个接收者的数据不同时每人一组，各组按 WithBatchConcurrency 并发发送
+func TestPushTemplateConcurrency(t *testing.T) {
+	transport := &concurrencyTransport{}
+	c, srv := newTestClient(t, WithTransport(transport), WithBatchConcurrency(3))
+	tmpl := newTestTemplate(t)
+
+	recipients := make([]TemplateRecipient, 20)
+	for i, account := range makeTargets("user", len(recipients)) {
+		recipients[i] = TemplateRecipient{Account: account, Locale: "en", Data: map[string]interface{}{"nickname": account, "count": i}}
+	}
+	res, err := c.PushAccountListTemplate(context.Background(), tmpl, recipients)
+	if err != nil || len(res.Groups) != 20 {
+		t.Fatalf("PushAccountListTemplate: %+v, %v", res, err)
+	}
+	for _, g := range res.Groups {
+		if g.Response == nil || g.Err != nil {
+			t.Errorf("group %v: %+v, %v", g.Recipients, g.Response, g.Err)
+		}
+	}
+	if len(srv.Pushes()) != 20 {
+		t.Errorf("server received %d pushes, want 20", len(srv.Pushes()))
+	}
+	if transport.max > 3 {
+		t.Errorf("max concurrent requests = %d, want <= 3", transport.max)
+	}
+}
@@ -1,5 +1,7 @@
 package xinge
 
+import "fmt"
+
 type TimeInterval struct {
 	StartTime *TimePart `json:"start"`
 	EndTime   *TimePart `json:"end"`
@@ -15,11 +17,26 @@ func DefaultTimeInterval() *TimeInterval {
 }
 
 func (s *TimeInterval) IsValid() bool {
-	if s.StartTime.Hour >= 0 && s.StartTime.Hour <= 23 &&
-		s.StartTime.Min >= 0 && s.StartTime.Min <= 59 &&
-		s.EndTime.Hour >= 0 && s.EndTime.Hour <= 23 &&
-		s.EndTime.Min >= 0 && s.EndTime.Min <= 59 {
-		return true
+	return s.Validate() == nil
+}
+
+// 校验起止时间都已设置，小时在 0~23、分钟在 0~59 之间
+func (s *TimeInterval) Validate() error {
+	var errs ValidationErrors
+	s.StartTime.validate(&errs, "start")
+	s.EndTime.validate(&errs, "end")
+	return errs.err()
+}
+
+func (s *TimePart) validate(errs *ValidationErrors, field string) {
+	if s == nil {
+		errs.add(field, "required")
+		return
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		errs.add(field+".hour", fmt.Sprintf("must be between 0 and 23, got %d", s.Hour))
+	}
+	if s.Min < 0 || s.Min > 59 {
+		errs.add(field+".min", fmt.Sprintf("must be between 0 and 59, got %d", s.Min))
 	}
-	return false
 }
@@ -0,0 +1,93 @@
+package xinge
+
+import (
+	"path"
+	"strings"
+)
+
+// 跨平台的消息，按需渲染成 MessageAndroid 或 MessageIOS，配合 DualClient 一次推送到两个平台
+type UniversalMessage struct {
+	Title         string                 // 通知标题，iOS 显示在正文上方
+	Body          string                 // 通知正文
+	Custom        map[string]interface{} // 自定义参数，两个平台都会带上
+	Badge         int                    // 角标数字，Android 对应 Style.BadgeType，0 表示不设置
+	Sound         string                 // 提示音文件名，Android 对应 res/raw 下的同名资源
+	ClickURL      string                 // 点击通知打开的网页，iOS 放在自定义参数 url 中由 App 处理
+	ClickActivity string                 // Android 点击通知打开的 Activity
+	ExpireTime    int                    // 离线消息存储时间（秒），0 表示使用 DEFAULT_EXPIRE_TIME
+	SendTime      string                 // 定时推送时间，格式为 DATETIMEFORMAT
+}
+
+func NewUniversalMessage(title, body string) *UniversalMessage {
+	return &UniversalMessage{Title: title, Body: body}
+}
+
+// 渲染成 Android 通知
+func (m *UniversalMessage) ToAndroid() *MessageAndroid {
+	msg := EasyMessageAndroid(m.Title, m.Body)
+	msg.Custom = copyCustom(m.Custom)
+	if m.ExpireTime != 0 {
+		msg.ExpireTime = m.ExpireTime
+	}
+	if m.SendTime != "" {
+		msg.SendTime = m.SendTime
+	}
+
+	if m.Badge != 0 {
+		msg.Style.SetBadgeType(m.Badge)
+	}
+	if m.Sound != "" {
+		msg.Style.Ring = true
+		msg.Style.RingRaw = strings.TrimSuffix(m.Sound, path.Ext(m.Sound))
+	}
+
+	if m.ClickURL != "" {
+		msg.ClickAction.SetActionType(TYPE_URL)
+		msg.ClickAction.Browser.SetUrl(m.ClickURL)
+	} else if m.ClickActivity != "" {
+		msg.ClickAction.SetActivity(m.ClickActivity)
+	}
+	return msg
+}
+
+// 渲染成 iOS 的 APNs 通知，env 为 IOSENV_PROD 或 IOSENV_DEV
+func (m *UniversalMessage) ToIOS(env Environment) *MessageIOS {
+	msg := EasyMessageIOS(m.Body, env)
+	if m.Title != "" {
+		msg.SetApsAlert(NewApsAlert(m.Title, m.Body))
+	}
+	msg.Custom = copyCustom(m.Custom)
+	msg.Badge = nil
+	if m.Badge != 0 {
+		msg.SetBadge(m.Badge)
+	}
+	if m.ExpireTime != 0 {
+		msg.ExpireTime = m.ExpireTime
+	}
+	if m.SendTime != "" {
+		msg.SendTime = m.SendTime
+	}
+	if m.Sound != "" {
+		msg.Sound = m.Sound
+	}
+
+	if m.ClickURL != "" {
+		if msg.Custom == nil {
+			msg.Custom = map[string]interface{}{}
+		}
+		msg.Custom["url"] = m.ClickURL
+	}
+	return msg
+}
+
+// 复制一份自定义参数，避免两个平台的消息共用同一个 map
+func copyCustom(custom map[string]interface{}) map[string]interface{} {
+	if custom == nil {
+		return nil
+	}
+	cp := make(map[string]interface{}, len(custom))
+	for k, v := range custom {
+		cp[k] = v
+	}
+	return cp
+}
@@ -2,10 +2,10 @@ package xinge
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -16,19 +16,19 @@ import (
 )
 
 const (
-	DEVICE_ALL                         int    = 0
-	DEVICE_BROWSER                     int    = 1
-	DEVICE_PC                          int    = 2
-	DEVICE_WINPHONE                    int    = 5
-	DEVICE_ANDROID                     int    = 3
-	DEVICE_IOS                         int    = 4
-	IOSENV_PROD                        int    = 1
-	IOSENV_DEV                         int    = 2
-	IOS_MIN_ID                         int64  = 2200000000
-	RESTAPI_DOMAIN                     string = "http://openapi.xg.qq.com"
-	HTTP_GET                           string = "GET"
-	HTTP_POST                          string = "POST"
-	CONTENT_TYPE_X_WWW_FORM_URLENCODED string = "application/x-www-form-urlencoded"
+	DEVICE_ALL                         DeviceType  = 0
+	DEVICE_BROWSER                     DeviceType  = 1
+	DEVICE_PC                          DeviceType  = 2
+	DEVICE_WINPHONE                    DeviceType  = 5
+	DEVICE_ANDROID                     DeviceType  = 3
+	DEVICE_IOS                         DeviceType  = 4
+	IOSENV_PROD                        Environment = 1
+	IOSENV_DEV                         Environment = 2
+	IOS_MIN_ID                         int64       = 2200000000
+	RESTAPI_DOMAIN                     string      = "http://openapi.xg.qq.com"
+	HTTP_GET                           string      = "GET"
+	HTTP_POST                          string      = "POST"
+	CONTENT_TYPE_X_WWW_FORM_URLENCODED string      = "application/x-www-form-urlencoded"
 )
 
 var (
@@ -56,13 +56,61 @@ var (
 
 // 信鸽 Client 结构体
 type Client struct {
-	accessId  int64
-	secretKey string
+	accessId   int64
+	secretKey  string
+	httpClient *http.Client
+	transport  http.RoundTripper
+	timeout    time.Duration
+	baseURL    string
+	userAgent  string
+
+	retryPolicy       *RetryPolicy
+	limiters          map[string]*tokenBucket
+	defaultLimiter    *tokenBucket
+	rateLimitFailFast bool
+
+	androidPayloadLimit int
+	iosPayloadLimit     int
+	autoTruncate        bool
+
+	batchConcurrency int
+}
+
+// 实例化信鸽 Client 结构体，给 accessId, secretKey 赋值，opts 可定制 HTTP 传输、接口域名等（见 Option）
+func NewClient(accessId int64, secretKey string, opts ...Option) *Client {
+	c := &Client{
+		accessId:   accessId,
+		secretKey:  secretKey,
+		httpClient: http.DefaultClient,
+		baseURL:    RESTAPI_DOMAIN,
+
+		androidPayloadLimit: ANDROID_MAX_PAYLOAD,
+		iosPayloadLimit:     IOS_MAX_PAYLOAD,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// Transport 和 Timeout 作用在 http.Client 的副本上，避免修改调用方传入的（或全局默认的）实例
+	if c.transport != nil || c.timeout > 0 {
+		hc := *c.httpClient
+		if c.transport != nil {
+			hc.Transport = c.transport
+		}
+		if c.timeout > 0 {
+			hc.Timeout = c.timeout
+		}
+		c.httpClient = &hc
+	}
+	return c
 }
 
-// 实例化信鸽 Client 结构体，给 accessId, secretKey 赋值
-func NewClient(accessId int64, secretKey string) *Client {
-	return &Client{accessId, secretKey}
+// 把 RESTAPI_* 接口地址的域名部分替换为 Client 配置的 baseURL
+func (c *Client) endpoint(uri string) string {
+	if c.baseURL == RESTAPI_DOMAIN || !strings.HasPrefix(uri, RESTAPI_DOMAIN) {
+		return uri
+	}
+	return c.baseURL + strings.TrimPrefix(uri, RESTAPI_DOMAIN)
 }
 
 // 检验 Token 参数
@@ -73,10 +121,39 @@ func (c *Client) validateToken(token string) bool {
 	return len(token) == 40 || len(token) == 64
 }
 
+// 校验 token 后把 tagTokenPairs 编码成 tag_token_list 参数
+func (c *Client) encodeTagTokenPairs(tagTokenPairs []TagTokenPair) (string, error) {
+	l := len(tagTokenPairs)
+	if l == 0 {
+		return "", newValidationError("tag_token_list", "invalid TagTokenPair length")
+	}
+	if l > TAG_TOKEN_LIST_MAX_SIZE {
+		return "", newValidationError("tag_token_list", "at most 20 pairs per call, use SetTags or DeleteTags for more")
+	}
+
+	for i, pair := range tagTokenPairs {
+		if err := c.validateTagTokenPair(pair); err != nil {
+			var errs ValidationErrors
+			errs.addNested(fmt.Sprintf("tag_token_list[%d]", i), err)
+			return "", errs.err()
+		}
+	}
+
+	byt, err := json.Marshal(tagTokenList(tagTokenPairs))
+	if err != nil {
+		return "", newValidationError("tag_token_list", err.Error())
+	}
+	return string(byt), nil
+}
+
 // 检验设备类型
-func (c *Client) validateMessageType(message Message) (deviceType int, err error) {
+func (c *Client) validateMessageType(message Message) (deviceType DeviceType, err error) {
 	if c == nil {
-		return DEVICE_ALL, errors.New("xinge client nil!")
+		return DEVICE_ALL, newValidationError("client", "xinge client nil")
+	}
+
+	if message == nil {
+		return DEVICE_ALL, newValidationError("message", "message nil")
 	}
 
 	if c.accessId < IOS_MIN_ID {
@@ -85,60 +162,135 @@ func (c *Client) validateMessageType(message Message) (deviceType int, err error
 		return DEVICE_IOS, nil
 	}
 
-	return DEVICE_ALL, errors.New("unknown message type!")
+	return DEVICE_ALL, newValidationError("environment", "unknown message type")
 }
 
 // 准备必要参数，调用信鸽的 Restful 接口， 正式发起 Push 推送（Push 推送专用函数）
 func (c *Client) push(uri string, message Message, params map[string]interface{}) XgResponse {
-	if _, err := c.validateMessageType(message); err != nil {
-		return NewRespone(-1, err.Error())
+	return toXgResponse(c.doPush(context.Background(), uri, message, params))
+}
+
+// 同 push，返回 error 风格的结果
+func (c *Client) doPush(ctx context.Context, uri string, message Message, params map[string]interface{}) (*XgResponse, error) {
+	deviceType, err := c.validateMessageType(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := message.Validate(); err != nil {
+		return nil, err
 	}
 
+	message, err = c.fitPayload(deviceType, message)
+	if err != nil {
+		return nil, err
+	}
+	params["message"] = message.ToJSON()
+
 	// 消息类型：1：通知 2：透传消息。iOS平台请填0；默认1：通知
-	params["message_type"] = message.GetType()
+	params["message_type"] = int(message.GetType())
 	//向iOS设备推送时必填，1表示推送生产环境；2表示推送开发环境。推送Android平台不填或填0
-	params["environment"] = message.GetEnvironment()
+	params["environment"] = int(message.GetEnvironment())
 	// 消息类型：1：通知 2：透传消息。iOS平台请填0；默认1：通知
 	//0表示按注册时提供的包名分发消息；1表示按access id分发消息，所有以该access id成功注册推送的app均可收到消息。本字段对iOS平台无效
-	params["multi_pkg"] = message.GetMultiPkg()
-
-	params["expire_time"] = 600
-	params["send_time"] = time.Now().Unix()
+	params["multi_pkg"] = boolInt(message.GetMultiPkg())
+
+	//消息离线存储时间（单位为秒），最长存储时间3天。若设置为0，则使用默认值（3天）
+	params["expire_time"] = message.GetExpireTime()
+	//指定推送时间，格式为year-mon-day hour:min:sec，若晚于当前时间则为定时推送，为空时立即推送
+	sendTime := message.GetSendTime()
+	if sendTime == "" {
+		sendTime = time.Now().Format(DATETIMEFORMAT)
+	}
+	params["send_time"] = sendTime
 
-	return c.callRestful(uri, params)
+	return c.do(ctx, uri, params)
 }
 
 //接收传入的必要参数， 调用信鸽的 Restful 接口，发起 POST 请求
 func (c *Client) callRestful(uri string, params map[string]interface{}) XgResponse {
+	return toXgResponse(c.do(context.Background(), uri, params))
+}
+
+// 同 callRestful，返回 error 风格的结果，错误类型见 CheckedClient。
+// 配置了 RetryPolicy 时按策略重试，每次重试都使用新的 timestamp 重新签名
+func (c *Client) do(ctx context.Context, uri string, params map[string]interface{}) (*XgResponse, error) {
+	p := c.retryPolicy
+	if p == nil || p.MaxAttempts <= 1 || (nonIdempotentEndpoints[uri] && !p.RetryNonIdempotent) {
+		if err := c.waitRateLimit(ctx, uri); err != nil {
+			return nil, err
+		}
+		return c.doOnce(ctx, c.endpoint(uri), params)
+	}
+
+	endpoint := c.endpoint(uri)
+	for attempt := 1; ; attempt++ {
+		if err := c.waitRateLimit(ctx, uri); err != nil {
+			return nil, err
+		}
+
+		attemptParams := make(map[string]interface{}, len(params)+3)
+		for k, v := range params {
+			attemptParams[k] = v
+		}
+
+		res, err := c.doOnce(ctx, endpoint, attemptParams)
+		if attempt >= p.MaxAttempts || !p.shouldRetry(ctx, err) {
+			return res, err
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+	}
+}
+
+// 发起一次签名后的 POST 请求，uri 为已替换过域名的完整地址
+func (c *Client) doOnce(ctx context.Context, uri string, params map[string]interface{}) (*XgResponse, error) {
 	params["access_id"] = c.accessId
 	params["timestamp"] = time.Now().Unix()
-	params["sign"] = generateSign(HTTP_POST, uri, c.secretKey, params)
 
-	var buf bytes.Buffer
+	// 先把参数统一转成字符串，签名和请求体使用完全相同的值，请求体再做 URL 编码
+	values := make(url.Values, len(params)+1)
 	for k, v := range params {
-		buf.WriteString(fmt.Sprintf("%s=%v&", k, v))
+		values.Set(k, fmt.Sprintf("%v", v))
 	}
+	values.Set("sign", generateSign(HTTP_POST, uri, c.secretKey, values))
 
-	// fmt.Println(strings.TrimRight(buf.String(), "&"))
+	req, err := http.NewRequestWithContext(ctx, HTTP_POST, uri, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, &TransportError{Op: "new request", URL: uri, Err: err}
+	}
+	req.Header.Set("Content-Type", CONTENT_TYPE_X_WWW_FORM_URLENCODED)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
-	r, err := http.Post(uri, CONTENT_TYPE_X_WWW_FORM_URLENCODED, strings.NewReader(strings.TrimRight(buf.String(), "&")))
+	r, err := c.httpClient.Do(req)
 	if err != nil {
-		return NewRespone(-1, "http post data err!")
+		return nil, &TransportError{Op: "post", URL: uri, Err: err}
 	}
+	defer r.Body.Close()
+
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		return NewRespone(-1, "read response data err!")
+		return nil, &TransportError{Op: "read response", URL: uri, Err: err}
 	}
 
-	// fmt.Println(string(body))
-
 	var res XgResponse
 	err = json.Unmarshal(body, &res)
 	if err != nil {
-		return NewRespone(-1, fmt.Sprintf("json unmarshal fail:%s", err.Error()))
+		return nil, &TransportError{Op: "decode response", URL: uri, Err: err}
+	}
+
+	if res.Code != 0 {
+		return &res, &APIError{Code: res.Code, Msg: res.Msg}
 	}
-	//fmt.Println(res)
-	return res
+	return &res, nil
 }
 
 // 信鸽响应结构体
@@ -179,7 +331,7 @@ func RespSuccess() XgResponse {
 }
 
 // 生成签名
-func generateSign(method, uri, secretKey string, params map[string]interface{}) string {
+func generateSign(method, uri, secretKey string, params url.Values) string {
 	u, err := url.Parse(uri)
 	if err != nil {
 		panic(err)
@@ -189,7 +341,10 @@ func generateSign(method, uri, secretKey string, params map[string]interface{})
 
 	keys := sortKey(params)
 	for _, k := range keys {
-		buf.WriteString(fmt.Sprintf(`%s=%v`, k, params[k]))
+		if k == "sign" {
+			continue
+		}
+		buf.WriteString(k + "=" + params.Get(k))
 	}
 	buf.WriteString(secretKey)
 	tmp := md5.Sum([]byte(buf.String()))
@@ -197,7 +352,7 @@ func generateSign(method, uri, secretKey string, params map[string]interface{})
 }
 
 //给参数的 key asc排序（升序）
-func sortKey(p map[string]interface{}) []string {
+func sortKey(p url.Values) []string {
 	keys := make([]string, 0)
 	for k, _ := range p {
 		keys = append(keys, k)
@@ -224,7 +379,6 @@ func PushTokenAndroid(accessId int64, secretKey, title, content, deviceToken str
 	params := initParams()
 	params["device_token"] = deviceToken
 	message := EasyMessageAndroid(title, content)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHSINGLEDEVICE, message, params)
 }
@@ -236,7 +390,6 @@ func PushAccountAndroid(accessId int64, secretKey, title, content, account strin
 	params := initParams()
 	params["account"] = account
 	message := EasyMessageAndroid(title, content)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHSINGLEACCOUNT, message, params)
 }
@@ -247,7 +400,6 @@ func PushAccountAndroid(accessId int64, secretKey, title, content, account strin
 func PushAllAndroid(accessId int64, secretKey, title, content string) XgResponse {
 	params := initParams()
 	message := EasyMessageAndroid(title, content)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHALLDEVICE, message, params)
 }
@@ -267,11 +419,10 @@ func PushTagAndroid(accessId int64, secretKey, title, content, tag string) XgRes
 /**
  * iOS 平台推送消息给单个设备
  */
-func PushTokenIOS(accessId int64, secretKey, content, deviceToken string, env int) XgResponse {
+func PushTokenIOS(accessId int64, secretKey, content, deviceToken string, env Environment) XgResponse {
 	params := initParams()
 	params["device_token"] = deviceToken
 	message := EasyMessageIOS(content, env)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHSINGLEDEVICE, message, params)
 }
@@ -279,11 +430,10 @@ func PushTokenIOS(accessId int64, secretKey, content, deviceToken string, env in
 /**
  * iOS 平台推送消息给单个账号
  */
-func PushAccountIOS(accessId int64, secretKey, content, account string, env int) XgResponse {
+func PushAccountIOS(accessId int64, secretKey, content, account string, env Environment) XgResponse {
 	params := initParams()
 	params["account"] = account
 	message := EasyMessageIOS(content, env)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHSINGLEACCOUNT, message, params)
 }
@@ -291,10 +441,9 @@ func PushAccountIOS(accessId int64, secretKey, content, account string, env int)
 /**
  * iOS 平台推送消息给所有设备
  */
-func PushAllIOS(accessId int64, secretKey, content string, env int) XgResponse {
+func PushAllIOS(accessId int64, secretKey, content string, env Environment) XgResponse {
 	params := initParams()
 	message := EasyMessageIOS(content, env)
-	params["message"] = message.ToJSON()
 	c := NewClient(accessId, secretKey)
 	return c.push(RESTAPI_PUSHALLDEVICE, message, params)
 }
@@ -302,7 +451,7 @@ func PushAllIOS(accessId int64, secretKey, content string, env int) XgResponse {
 /**
  * iOS 平台推送消息给标签选中设备
  */
-func PushTagIOS(accessId int64, secretKey, content, tag string, env int) XgResponse {
+func PushTagIOS(accessId int64, secretKey, content, tag string, env Environment) XgResponse {
 	message := EasyMessageIOS(content, env)
 	c := NewClient(accessId, secretKey)
 	tagList := []string{tag}
@@ -318,10 +467,12 @@ func PushTagIOS(accessId int64, secretKey, content, tag string, env int) XgRespo
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushSingleDevice(deviceToken string, message Message) XgResponse {
-	params := initParams()
-	params["device_token"] = deviceToken
-	params["message"] = message.ToJSON()
-	return c.push(RESTAPI_PUSHSINGLEDEVICE, message, params)
+	return c.PushSingleDeviceContext(context.Background(), deviceToken, message)
+}
+
+// PushSingleDeviceContext 同 PushSingleDevice。各 XxxContext 方法在 ctx 超时或取消时中断正在进行的请求
+func (c *Client) PushSingleDeviceContext(ctx context.Context, deviceToken string, message Message) XgResponse {
+	return toXgResponse(c.Checked().PushSingleDevice(ctx, deviceToken, message))
 }
 
 /**
@@ -333,11 +484,12 @@ func (c *Client) PushSingleDevice(deviceToken string, message Message) XgRespons
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushSingleAccount(account string, message Message) XgResponse {
-	params := initParams()
-	params["account"] = account
-	params["message"] = message.ToJSON()
-	//`{"accept_time":[],"action":{"action_type":1,"browser":{},"aty_attr":{}},"builder_id":0,"clearable":1,"content":"测试信鸽推送 Android API","custom_content":null,"icon_res":"","icon_type":0,"lights":1,"n_id":0,"ring":0,"ring_raw":"","small_icon":"","style_id":1,"title":"哎菠菜","vibrate":1}`
-	return c.push(RESTAPI_PUSHSINGLEACCOUNT, message, params)
+	return c.PushSingleAccountContext(context.Background(), account, message)
+}
+
+// PushSingleAccountContext 同 PushSingleAccount
+func (c *Client) PushSingleAccountContext(ctx context.Context, account string, message Message) XgResponse {
+	return toXgResponse(c.Checked().PushSingleAccount(ctx, account, message))
 }
 
 /**
@@ -350,14 +502,12 @@ func (c *Client) PushSingleAccount(account string, message Message) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushAccountList(accountList []string, message Message) XgResponse {
-	params := initParams()
-	account_list, err := json.Marshal(accountList)
-	if err != nil {
-		return NewRespone(-1, "json marshal fial!")
-	}
-	params["account_list"] = string(account_list)
-	params["message"] = message.ToJSON()
-	return c.push(RESTAPI_PUSHACCOUNTLIST, message, params)
+	return c.PushAccountListContext(context.Background(), accountList, message)
+}
+
+// PushAccountListContext 同 PushAccountList
+func (c *Client) PushAccountListContext(ctx context.Context, accountList []string, message Message) XgResponse {
+	return toXgResponse(c.Checked().PushAccountList(ctx, accountList, message))
 }
 
 /**
@@ -368,9 +518,12 @@ func (c *Client) PushAccountList(accountList []string, message Message) XgRespon
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushAllDevices(message Message) XgResponse {
-	params := initParams()
-	params["message"] = message.ToJSON()
-	return c.push(RESTAPI_PUSHALLDEVICE, message, params)
+	return c.PushAllDevicesContext(context.Background(), message)
+}
+
+// PushAllDevicesContext 同 PushAllDevices
+func (c *Client) PushAllDevicesContext(ctx context.Context, message Message) XgResponse {
+	return toXgResponse(c.Checked().PushAllDevices(ctx, message))
 }
 
 /**
@@ -384,25 +537,12 @@ func (c *Client) PushAllDevices(message Message) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushTags(tagList []string, tagOp string, message Message) XgResponse {
-	if !message.IsValid() || len(tagList) <= 0 || (tagOp != "AND" && tagOp != "OR") {
-		return NewRespone(-1, "param invalid!")
-	}
-
-	params := initParams()
-	tagListByt, err := json.Marshal(tagList)
-	if err != nil {
-		return NewRespone(-1, "json marshal fail!")
-	}
-	params["tags_list"] = string(tagListByt)
-	params["tags_op"] = tagOp
-	params["message"] = message.ToJSON()
-
-	if message.GetLoopInterval() > 0 && message.GetLoopTimes() > 0 {
-		params["loop_interval"] = message.GetLoopInterval()
-		params["loop_times"] = message.GetLoopTimes()
-	}
+	return c.PushTagsContext(context.Background(), tagList, tagOp, message)
+}
 
-	return c.push(RESTAPI_PUSHTAGS, message, params)
+// PushTagsContext 同 PushTags
+func (c *Client) PushTagsContext(ctx context.Context, tagList []string, tagOp string, message Message) XgResponse {
+	return toXgResponse(c.Checked().PushTags(ctx, tagList, tagOp, message))
 }
 
 /**
@@ -413,15 +553,13 @@ func (c *Client) PushTags(tagList []string, tagOp string, message Message) XgRes
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) CreateMultipush(message Message) int64 {
-	if !message.IsValid() {
-		return 0
-	}
-
-	params := initParams()
-	params["message"] = message.ToJSON()
+	return c.CreateMultipushContext(context.Background(), message)
+}
 
-	res := c.push(RESTAPI_CREATEMULTIPUSH, message, params)
-	if res.XgResult == nil {
+// CreateMultipushContext 同 CreateMultipush
+func (c *Client) CreateMultipushContext(ctx context.Context, message Message) int64 {
+	res, err := c.Checked().CreateMultipush(ctx, message)
+	if err != nil || res.XgResult == nil {
 		return 0
 	}
 
@@ -441,23 +579,12 @@ func (c *Client) CreateMultipush(message Message) int64 {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushAccountListMultiple(pushId int64, accountList []string) XgResponse {
-	if pushId <= 0 {
-		return NewRespone(-1, "pushId invalid!")
-	}
-
-	if len(accountList) <= 0 {
-		return NewRespone(-1, "param invalid!")
-	}
-
-	params := initParams()
-	params["push_id"] = pushId
-	accountListByt, err := json.Marshal(accountList)
-	if err != nil {
-		return NewRespone(-1, "json marshal fial!")
-	}
-	params["account_list"] = string(accountListByt)
+	return c.PushAccountListMultipleContext(context.Background(), pushId, accountList)
+}
 
-	return c.callRestful(RESTAPI_PUSHACCOUNTLISTMULTIPLE, params)
+// PushAccountListMultipleContext 同 PushAccountListMultiple
+func (c *Client) PushAccountListMultipleContext(ctx context.Context, pushId int64, accountList []string) XgResponse {
+	return toXgResponse(c.Checked().PushAccountListMultiple(ctx, pushId, accountList))
 }
 
 /**
@@ -469,23 +596,12 @@ func (c *Client) PushAccountListMultiple(pushId int64, accountList []string) XgR
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) PushDeviceListMultiple(pushId int64, deviceList []string) XgResponse {
-	if pushId <= 0 {
-		return NewRespone(-1, "pushId invalid!")
-	}
-
-	if len(deviceList) <= 0 {
-		return NewRespone(-1, "param invalid!")
-	}
-
-	params := initParams()
-	params["push_id"] = pushId
-	deviceListByt, err := json.Marshal(deviceList)
-	if err != nil {
-		return NewRespone(-1, "json marshal fial!")
-	}
-	params["device_list"] = string(deviceListByt)
+	return c.PushDeviceListMultipleContext(context.Background(), pushId, deviceList)
+}
 
-	return c.callRestful(RESTAPI_PUSHDEVICELISTMULTIPLE, params)
+// PushDeviceListMultipleContext 同 PushDeviceListMultiple
+func (c *Client) PushDeviceListMultipleContext(ctx context.Context, pushId int64, deviceList []string) XgResponse {
+	return toXgResponse(c.Checked().PushDeviceListMultiple(ctx, pushId, deviceList))
 }
 
 /**
@@ -495,24 +611,12 @@ func (c *Client) PushDeviceListMultiple(pushId int64, deviceList []string) XgRes
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryPushStatus(pushIdList []string) XgResponse {
-	l := len(pushIdList)
-	if l == 0 {
-		return NewRespone(-1, "pushId slice len eq zero!")
-	}
-
-	params := initParams()
-	buf := bytes.NewBufferString("[")
-	for i := 0; i < l; i++ {
-		if i == l-1 {
-			buf.WriteString(`{"push_id":"` + pushIdList[i] + `"}`)
-		} else {
-			buf.WriteString(`{"push_id":"` + pushIdList[i] + `"},`)
-		}
-	}
-	buf.WriteString(`]`)
-	params["push_ids"] = buf.String()
+	return c.QueryPushStatusContext(context.Background(), pushIdList)
+}
 
-	return c.callRestful(RESTAPI_QUERYPUSHSTATUS, params)
+// QueryPushStatusContext 同 QueryPushStatus
+func (c *Client) QueryPushStatusContext(ctx context.Context, pushIdList []string) XgResponse {
+	return toXgResponse(c.Checked().QueryPushStatus(ctx, pushIdList))
 }
 
 /**
@@ -521,8 +625,12 @@ func (c *Client) QueryPushStatus(pushIdList []string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryDeviceCount() XgResponse {
-	params := initParams()
-	return c.callRestful(RESTAPI_QUERYDEVICECOUNT, params)
+	return c.QueryDeviceCountContext(context.Background())
+}
+
+// QueryDeviceCountContext 同 QueryDeviceCount
+func (c *Client) QueryDeviceCountContext(ctx context.Context) XgResponse {
+	return toXgResponse(c.Checked().QueryDeviceCount(ctx))
 }
 
 /**
@@ -533,10 +641,12 @@ func (c *Client) QueryDeviceCount() XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryTags(start, limit int64) XgResponse {
-	params := initParams()
-	params["start"] = start
-	params["limit"] = limit
-	return c.callRestful(RESTAPI_QUERYTAGS, params)
+	return c.QueryTagsContext(context.Background(), start, limit)
+}
+
+// QueryTagsContext 同 QueryTags
+func (c *Client) QueryTagsContext(ctx context.Context, start, limit int64) XgResponse {
+	return toXgResponse(c.Checked().QueryTags(ctx, start, limit))
 }
 
 /**
@@ -545,7 +655,12 @@ func (c *Client) QueryTags(start, limit int64) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryTagsBefore100() XgResponse {
-	return c.QueryTags(0, 100)
+	return c.QueryTagsBefore100Context(context.Background())
+}
+
+// QueryTagsBefore100Context 同 QueryTagsBefore100
+func (c *Client) QueryTagsBefore100Context(ctx context.Context) XgResponse {
+	return toXgResponse(c.Checked().QueryTagsBefore100(ctx))
 }
 
 /**
@@ -555,9 +670,12 @@ func (c *Client) QueryTagsBefore100() XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryTagTokenNum(tag string) XgResponse {
-	params := initParams()
-	params["tag"] = tag
-	return c.callRestful(RESTAPI_QUERYTAGTOKENNUM, params)
+	return c.QueryTagTokenNumContext(context.Background(), tag)
+}
+
+// QueryTagTokenNumContext 同 QueryTagTokenNum
+func (c *Client) QueryTagTokenNumContext(ctx context.Context, tag string) XgResponse {
+	return toXgResponse(c.Checked().QueryTagTokenNum(ctx, tag))
 }
 
 /**
@@ -567,9 +685,12 @@ func (c *Client) QueryTagTokenNum(tag string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryTokenTags(device_token string) XgResponse {
-	params := initParams()
-	params["device_token"] = device_token
-	return c.callRestful(RESTAPI_QUERYTOKENTAGS, params)
+	return c.QueryTokenTagsContext(context.Background(), device_token)
+}
+
+// QueryTokenTagsContext 同 QueryTokenTags
+func (c *Client) QueryTokenTagsContext(ctx context.Context, device_token string) XgResponse {
+	return toXgResponse(c.Checked().QueryTokenTags(ctx, device_token))
 }
 
 /**
@@ -579,69 +700,42 @@ func (c *Client) QueryTokenTags(device_token string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) CancelTimingPush(pushId string) XgResponse {
-	params := initParams()
-	params["push_id"] = pushId
-	return c.callRestful(RESTAPI_CANCELTIMINGPUSH, params)
+	return c.CancelTimingPushContext(context.Background(), pushId)
+}
+
+// CancelTimingPushContext 同 CancelTimingPush
+func (c *Client) CancelTimingPushContext(ctx context.Context, pushId string) XgResponse {
+	return toXgResponse(c.Checked().CancelTimingPush(ctx, pushId))
 }
 
 /**
- * 批量为token设备标签，每次调用最多输入20个pair
+ * 批量为token设备标签，每次调用最多输入20个pair，更多时请使用 SetTags
  *
  * @param tagTokenPairs 指定token对应的指定tag
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) BatchSetTag(tagTokenPairs []TagTokenPair) XgResponse {
-	l := len(tagTokenPairs)
-	if l == 0 {
-		return NewRespone(-1, "invalid TagTokenPair length")
-	}
-
-	buf := bytes.NewBufferString(`[`)
-	for i := 0; i < l; i++ {
-		if !c.validateToken(tagTokenPairs[i].Token) {
-			return NewRespone(-1, "invalid token "+tagTokenPairs[i].Token)
-		}
-		if i == l-1 {
-			buf.WriteString(`["` + tagTokenPairs[i].Tag + `","` + tagTokenPairs[i].Token + `"]`)
-		} else {
-			buf.WriteString(`["` + tagTokenPairs[i].Tag + `","` + tagTokenPairs[i].Token + `"],`)
-		}
-	}
-	buf.WriteString(`]`)
+	return c.BatchSetTagContext(context.Background(), tagTokenPairs)
+}
 
-	params := initParams()
-	params["tag_token_list"] = buf.String()
-	return c.callRestful(RESTAPI_BATCHSETTAG, params)
+// BatchSetTagContext 同 BatchSetTag
+func (c *Client) BatchSetTagContext(ctx context.Context, tagTokenPairs []TagTokenPair) XgResponse {
+	return toXgResponse(c.Checked().BatchSetTag(ctx, tagTokenPairs))
 }
 
 /**
- * 批量为token删除标签，每次调用最多输入20个pair
+ * 批量为token删除标签，每次调用最多输入20个pair，更多时请使用 DeleteTags
  *
  * @param tagTokenPairs 指定token对应的指定tag
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) BatchDelTag(tagTokenPairs []TagTokenPair) XgResponse {
-	l := len(tagTokenPairs)
-	if l == 0 {
-		return NewRespone(-1, "invalid TagTokenPair length")
-	}
-
-	buf := bytes.NewBufferString(`[`)
-	for i := 0; i < l; i++ {
-		if !c.validateToken(tagTokenPairs[i].Token) {
-			return NewRespone(-1, "invalid token "+tagTokenPairs[i].Token)
-		}
-		if i == l-1 {
-			buf.WriteString(`["` + tagTokenPairs[i].Tag + `","` + tagTokenPairs[i].Token + `"]`)
-		} else {
-			buf.WriteString(`["` + tagTokenPairs[i].Tag + `","` + tagTokenPairs[i].Token + `"],`)
-		}
-	}
-	buf.WriteString(`]`)
+	return c.BatchDelTagContext(context.Background(), tagTokenPairs)
+}
 
-	params := initParams()
-	params["tag_token_list"] = buf.String()
-	return c.callRestful(RESTAPI_BATCHDELTAG, params)
+// BatchDelTagContext 同 BatchDelTag
+func (c *Client) BatchDelTagContext(ctx context.Context, tagTokenPairs []TagTokenPair) XgResponse {
+	return toXgResponse(c.Checked().BatchDelTag(ctx, tagTokenPairs))
 }
 
 /**
@@ -651,9 +745,12 @@ func (c *Client) BatchDelTag(tagTokenPairs []TagTokenPair) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryInfoOfToken(deviceToken string) XgResponse {
-	params := initParams()
-	params["device_token"] = deviceToken
-	return c.callRestful(RESTAPI_QUERYINFOOFTOKEN, params)
+	return c.QueryInfoOfTokenContext(context.Background(), deviceToken)
+}
+
+// QueryInfoOfTokenContext 同 QueryInfoOfToken
+func (c *Client) QueryInfoOfTokenContext(ctx context.Context, deviceToken string) XgResponse {
+	return toXgResponse(c.Checked().QueryInfoOfToken(ctx, deviceToken))
 }
 
 /**
@@ -663,9 +760,12 @@ func (c *Client) QueryInfoOfToken(deviceToken string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) QueryTokensOfAccount(account string) XgResponse {
-	params := initParams()
-	params["account"] = account
-	return c.callRestful(RESTAPI_QUERYTOKENSOFACCOUNT, params)
+	return c.QueryTokensOfAccountContext(context.Background(), account)
+}
+
+// QueryTokensOfAccountContext 同 QueryTokensOfAccount
+func (c *Client) QueryTokensOfAccountContext(ctx context.Context, account string) XgResponse {
+	return toXgResponse(c.Checked().QueryTokensOfAccount(ctx, account))
 }
 
 /**
@@ -676,10 +776,12 @@ func (c *Client) QueryTokensOfAccount(account string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) DeleteTokenOfAccount(account, deviceToken string) XgResponse {
-	params := initParams()
-	params["account"] = account
-	params["device_token"] = deviceToken
-	return c.callRestful(RESTAPI_DELETETOKENOFACCOUNT, params)
+	return c.DeleteTokenOfAccountContext(context.Background(), account, deviceToken)
+}
+
+// DeleteTokenOfAccountContext 同 DeleteTokenOfAccount
+func (c *Client) DeleteTokenOfAccountContext(ctx context.Context, account, deviceToken string) XgResponse {
+	return toXgResponse(c.Checked().DeleteTokenOfAccount(ctx, account, deviceToken))
 }
 
 /**
@@ -689,7 +791,10 @@ func (c *Client) DeleteTokenOfAccount(account, deviceToken string) XgResponse {
  * @return 服务器执行结果， XgResponse 实体
  */
 func (c *Client) DeleteAllTokensOfAccount(account string) XgResponse {
-	params := initParams()
-	params["account"] = account
-	return c.callRestful(RESTAPI_DELETEALLTOKENSOFACCOUNT, params)
+	return c.DeleteAllTokensOfAccountContext(context.Background(), account)
+}
+
+// DeleteAllTokensOfAccountContext 同 DeleteAllTokensOfAccount
+func (c *Client) DeleteAllTokensOfAccountContext(ctx context.Context, account string) XgResponse {
+	return toXgResponse(c.Checked().DeleteAllTokensOfAccount(ctx, account))
 }
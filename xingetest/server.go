@@ -0,0 +1,681 @@
+// xingetest 提供一个进程内的信鸽模拟服务，用于在单元测试中替代真实的信鸽接口。
+//
+// 模拟服务实现了 /v2/push/*、/v2/tags/*、/v2/application/* 下的全部接口，按照 SDK 的签名规则校验 sign，
+// 在内存中保存 token、账号、标签和推送记录，并支持注入失败响应。
+//
+//	srv := xingetest.NewServer(accessId, secretKey)
+//	defer srv.Close()
+//
+//	srv.RegisterToken(token)
+//	client := xinge.NewClient(accessId, secretKey, xinge.WithBaseURL(srv.URL))
+//	client.PushSingleDevice(token, message)
+//
+//	pushes := srv.Pushes() // 检查服务端收到的推送
+package xingetest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/panjunjie/xinge"
+)
+
+const (
+	DATETIMEFORMAT = "2006-01-02 15:04:05"
+
+	// 列表参数的长度上限，与信鸽接口一致
+	ACCOUNT_LIST_MAX_SIZE   = 100  // account_list 接口
+	MULTIPLE_LIST_MAX_SIZE  = 1000 // account_list_multiple、device_list_multiple 接口
+	TAG_TOKEN_LIST_MAX_SIZE = 20   // batch_set、batch_del 接口
+	TAG_QUERY_MAX_LIMIT     = 100  // query_app_tags 接口的 limit
+
+	// 请求 timestamp 与服务端时间允许的最大偏差
+	timestampWindow = 600
+)
+
+// 模拟服务收到的一次推送
+type Push struct {
+	PushId      int64
+	Path        string   // 接口路径，如 /v2/push/single_device
+	Message     string   // message 参数的原始 JSON
+	MessageType int      // message_type 参数
+	Environment int      // environment 参数
+	ExpireTime  int      // expire_time 参数
+	SendTime    string   // send_time 参数
+	Targets     []string // 推送目标：token、账号或标签，全量推送时为空
+	TagsOp      string   // tags_op 参数，仅标签推送
+	Params      url.Values
+	Status      xinge.PushStatus // 被取消的定时推送为 xinge.STATUS_FAILED
+	Finished    int64
+	Total       int64
+	Canceled    bool
+}
+
+type failure struct {
+	code int
+	msg  string
+	drop bool
+	hang bool
+}
+
+// 进程内的信鸽模拟服务
+type Server struct {
+	*httptest.Server
+
+	AccessId  int64
+	SecretKey string
+
+	mu         sync.Mutex
+	tokens     map[string]*tokenInfo
+	accounts   map[string]map[string]bool
+	tags       map[string]map[string]bool
+	pushes     []*Push
+	failures   map[string][]failure
+	requests   []url.Values
+	nextPushId int64
+	now        func() time.Time
+}
+
+type tokenInfo struct {
+	connTimestamp int64
+	msgsNum       int64
+}
+
+// 创建并启动模拟服务，只接受使用 accessId 和 secretKey 签名的请求
+func NewServer(accessId int64, secretKey string) *Server {
+	s := &Server{
+		AccessId:   accessId,
+		SecretKey:  secretKey,
+		tokens:     make(map[string]*tokenInfo),
+		accounts:   make(map[string]map[string]bool),
+		tags:       make(map[string]map[string]bool),
+		failures:   make(map[string][]failure),
+		nextPushId: 1000,
+		now:        time.Now,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// 注册一个设备 token
+func (s *Server) RegisterToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerToken(token)
+}
+
+// 把账号和 token 绑定，token 未注册时自动注册
+func (s *Server) BindAccount(account, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerToken(token)
+	if s.accounts[account] == nil {
+		s.accounts[account] = make(map[string]bool)
+	}
+	s.accounts[account][token] = true
+}
+
+// 下一次请求 path 接口时返回 ret_code 为 code 的错误响应，可多次调用排队
+func (s *Server) FailNext(path string, code int, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failure{code: code, msg: msg})
+}
+
+// 下一次请求 path 接口时直接断开连接，模拟网络错误
+func (s *Server) DropNext(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failure{drop: true})
+}
+
+// 下一次请求 path 接口时不返回响应，直到客户端取消请求，模拟服务端无响应
+func (s *Server) HangNext(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failure{hang: true})
+}
+
+// 设置推送任务的状态，用于模拟推送进度
+func (s *Server) SetPushStatus(pushId int64, status xinge.PushStatus, finished, total int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.findPush(pushId); p != nil {
+		p.Status = status
+		p.Finished = finished
+		p.Total = total
+	}
+}
+
+// 返回收到的全部推送（副本）
+func (s *Server) Pushes() []Push {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pushes := make([]Push, len(s.pushes))
+	for i, p := range s.pushes {
+		pushes[i] = *p
+	}
+	return pushes
+}
+
+// 返回收到的全部请求参数（已通过签名校验的）
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests...)
+}
+
+// 返回 token 下的所有标签，按字典序排列
+func (s *Server) TokenTags(token string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenTags(token)
+}
+
+// 返回应用的所有标签，按字典序排列
+func (s *Server) Tags() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedTags()
+}
+
+// 按信鸽的规则计算签名，与 SDK 的 generateSign 一致
+func Sign(method, host, path, secretKey string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(strings.ToUpper(method) + host + path)
+	for _, k := range keys {
+		buf.WriteString(k + "=" + params.Get(k))
+	}
+	buf.WriteString(secretKey)
+	sum := md5.Sum(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+type response struct {
+	Code   int         `json:"ret_code"`
+	Msg    string      `json:"err_msg,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+type handlerFunc func(s *Server, form url.Values) response
+
+var handlers = map[string]handlerFunc{
+	"/v2/push/single_device":                     (*Server).pushSingleDevice,
+	"/v2/push/single_account":                    (*Server).pushSingleAccount,
+	"/v2/push/account_list":                      (*Server).pushAccountList,
+	"/v2/push/all_device":                        (*Server).pushAllDevice,
+	"/v2/push/tags_device":                       (*Server).pushTags,
+	"/v2/push/create_multipush":                  (*Server).createMultipush,
+	"/v2/push/account_list_multiple":             (*Server).pushAccountListMultiple,
+	"/v2/push/device_list_multiple":              (*Server).pushDeviceListMultiple,
+	"/v2/push/get_msg_status":                    (*Server).queryPushStatus,
+	"/v2/push/cancel_timing_task":                (*Server).cancelTimingPush,
+	"/v2/application/get_app_device_num":         (*Server).queryDeviceCount,
+	"/v2/application/get_app_token_info":         (*Server).queryInfoOfToken,
+	"/v2/application/get_app_account_tokens":     (*Server).queryTokensOfAccount,
+	"/v2/application/del_app_account_tokens":     (*Server).deleteTokenOfAccount,
+	"/v2/application/del_app_account_all_tokens": (*Server).deleteAllTokensOfAccount,
+	"/v2/tags/query_app_tags":                    (*Server).queryTags,
+	"/v2/tags/query_token_tags":                  (*Server).queryTokenTags,
+	"/v2/tags/query_tag_token_num":               (*Server).queryTagTokenNum,
+	"/v2/tags/batch_set":                         (*Server).batchSetTag,
+	"/v2/tags/batch_del":                         (*Server).batchDelTag,
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := handlers[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, response{Code: -1, Msg: "parse form: " + err.Error()})
+		return
+	}
+	form := r.PostForm
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if queue := s.failures[r.URL.Path]; len(queue) > 0 {
+		f := queue[0]
+		s.failures[r.URL.Path] = queue[1:]
+		if f.hang {
+			// 等待期间不占用锁，其余请求照常处理
+			s.mu.Unlock()
+			<-r.Context().Done()
+			s.mu.Lock()
+			return
+		}
+		if f.drop {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, response{Code: f.code, Msg: f.msg})
+		return
+	}
+
+	if form.Get("access_id") != strconv.FormatInt(s.AccessId, 10) {
+		writeJSON(w, response{Code: 20, Msg: "access id error"})
+		return
+	}
+
+	ts, err := strconv.ParseInt(form.Get("timestamp"), 10, 64)
+	if err != nil || abs(s.now().Unix()-ts) > timestampWindow {
+		writeJSON(w, response{Code: -2, Msg: "timestamp expired"})
+		return
+	}
+
+	if sign := Sign(r.Method, r.Host, r.URL.Path, s.SecretKey, form); sign != form.Get("sign") {
+		writeJSON(w, response{Code: -3, Msg: "sign error"})
+		return
+	}
+
+	s.requests = append(s.requests, form)
+	writeJSON(w, handler(s, form))
+}
+
+func writeJSON(w http.ResponseWriter, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func paramError(msg string) response {
+	return response{Code: -1, Msg: msg}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (s *Server) registerToken(token string) {
+	if _, ok := s.tokens[token]; !ok {
+		s.tokens[token] = &tokenInfo{connTimestamp: s.now().Unix()}
+	}
+}
+
+func (s *Server) findPush(pushId int64) *Push {
+	for _, p := range s.pushes {
+		if p.PushId == pushId {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) tokenTags(token string) []string {
+	tags := make([]string, 0)
+	for tag, tokens := range s.tags {
+		if tokens[token] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (s *Server) sortedTags() []string {
+	tags := make([]string, 0, len(s.tags))
+	for tag := range s.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// 记录一次推送，定时推送（send_time 晚于当前时间）的状态为未处理，其余直接视为推送完成并投递给 tokens
+func (s *Server) recordPush(path string, form url.Values, targets []string, tokens []string) (*Push, response) {
+	message := form.Get("message")
+	if path != "/v2/push/account_list_multiple" && path != "/v2/push/device_list_multiple" {
+		if message == "" || !json.Valid([]byte(message)) {
+			return nil, paramError("message invalid")
+		}
+	}
+
+	s.nextPushId++
+	p := &Push{
+		PushId:   s.nextPushId,
+		Path:     path,
+		Message:  message,
+		SendTime: form.Get("send_time"),
+		Targets:  targets,
+		TagsOp:   form.Get("tags_op"),
+		Params:   form,
+		Status:   xinge.STATUS_FINISHED,
+		Finished: int64(len(tokens)),
+		Total:    int64(len(tokens)),
+	}
+	p.MessageType, _ = strconv.Atoi(form.Get("message_type"))
+	p.Environment, _ = strconv.Atoi(form.Get("environment"))
+	p.ExpireTime, _ = strconv.Atoi(form.Get("expire_time"))
+
+	if t, err := time.ParseInLocation(DATETIMEFORMAT, p.SendTime, time.Local); err == nil && t.After(s.now()) {
+		p.Status = xinge.STATUS_PENDING
+		p.Finished = 0
+	} else {
+		s.deliver(tokens)
+	}
+
+	s.pushes = append(s.pushes, p)
+	return p, response{Result: map[string]string{"push_id": strconv.FormatInt(p.PushId, 10)}}
+}
+
+// 累加 token 收到的消息数，即 QueryInfoOfToken 返回的 msgsNum
+func (s *Server) deliver(tokens []string) {
+	for _, token := range tokens {
+		if info, ok := s.tokens[token]; ok {
+			info.msgsNum++
+		}
+	}
+}
+
+// 账号绑定的 token，多个账号绑定同一个 token 时只返回一次
+func (s *Server) accountTokens(accounts ...string) []string {
+	seen := make(map[string]bool)
+	tokens := make([]string, 0)
+	for _, account := range accounts {
+		for token := range s.accounts[account] {
+			if !seen[token] {
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+func decodeStringList(form url.Values, key string) ([]string, bool) {
+	var list []string
+	if err := json.Unmarshal([]byte(form.Get(key)), &list); err != nil || len(list) == 0 {
+		return nil, false
+	}
+	return list, true
+}
+
+func (s *Server) pushSingleDevice(form url.Values) response {
+	token := form.Get("device_token")
+	if _, ok := s.tokens[token]; !ok {
+		return response{Code: 40, Msg: "token not registered"}
+	}
+	_, res := s.recordPush("/v2/push/single_device", form, []string{token}, []string{token})
+	return res
+}
+
+func (s *Server) pushSingleAccount(form url.Values) response {
+	account := form.Get("account")
+	if len(s.accounts[account]) == 0 {
+		return response{Code: 48, Msg: "account not bound"}
+	}
+	_, res := s.recordPush("/v2/push/single_account", form, []string{account}, s.accountTokens(account))
+	return res
+}
+
+func (s *Server) pushAccountList(form url.Values) response {
+	accounts, ok := decodeStringList(form, "account_list")
+	if !ok {
+		return paramError("account_list invalid")
+	}
+	if len(accounts) > ACCOUNT_LIST_MAX_SIZE {
+		return paramError("account_list too long")
+	}
+	_, res := s.recordPush("/v2/push/account_list", form, accounts, s.accountTokens(accounts...))
+	return res
+}
+
+func (s *Server) pushAllDevice(form url.Values) response {
+	tokens := make([]string, 0, len(s.tokens))
+	for token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	_, res := s.recordPush("/v2/push/all_device", form, nil, tokens)
+	return res
+}
+
+func (s *Server) pushTags(form url.Values) response {
+	tags, ok := decodeStringList(form, "tags_list")
+	if !ok {
+		return paramError("tags_list invalid")
+	}
+	op := form.Get("tags_op")
+	if op != "AND" && op != "OR" {
+		return paramError("tags_op invalid")
+	}
+
+	tokens := make([]string, 0)
+	for token := range s.tokens {
+		matched := op == "AND"
+		for _, tag := range tags {
+			if op == "AND" {
+				matched = matched && s.tags[tag][token]
+			} else {
+				matched = matched || s.tags[tag][token]
+			}
+		}
+		if matched {
+			tokens = append(tokens, token)
+		}
+	}
+	_, res := s.recordPush("/v2/push/tags_device", form, tags, tokens)
+	return res
+}
+
+func (s *Server) createMultipush(form url.Values) response {
+	p, res := s.recordPush("/v2/push/create_multipush", form, nil, nil)
+	if p != nil {
+		p.Status = xinge.STATUS_PUSHING
+	}
+	return res
+}
+
+func (s *Server) appendMultipush(form url.Values, key string) response {
+	pushId, _ := strconv.ParseInt(form.Get("push_id"), 10, 64)
+	p := s.findPush(pushId)
+	if p == nil || p.Path != "/v2/push/create_multipush" {
+		return paramError("push_id invalid")
+	}
+	list, ok := decodeStringList(form, key)
+	if !ok {
+		return paramError(key + " invalid")
+	}
+	if len(list) > MULTIPLE_LIST_MAX_SIZE {
+		return paramError(key + " too long")
+	}
+	p.Targets = append(p.Targets, list...)
+	p.Total += int64(len(list))
+	p.Finished += int64(len(list))
+	if key == "account_list" {
+		s.deliver(s.accountTokens(list...))
+	} else {
+		s.deliver(list)
+	}
+	return response{}
+}
+
+func (s *Server) pushAccountListMultiple(form url.Values) response {
+	return s.appendMultipush(form, "account_list")
+}
+
+func (s *Server) pushDeviceListMultiple(form url.Values) response {
+	return s.appendMultipush(form, "device_list")
+}
+
+func (s *Server) queryPushStatus(form url.Values) response {
+	var ids []struct {
+		PushId string `json:"push_id"`
+	}
+	if err := json.Unmarshal([]byte(form.Get("push_ids")), &ids); err != nil || len(ids) == 0 {
+		return paramError("push_ids invalid")
+	}
+
+	list := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		pushId, _ := strconv.ParseInt(id.PushId, 10, 64)
+		p := s.findPush(pushId)
+		if p == nil {
+			continue
+		}
+		list = append(list, map[string]interface{}{
+			"push_id":    id.PushId,
+			"status":     p.Status,
+			"start_time": p.SendTime,
+			"finished":   p.Finished,
+			"total":      p.Total,
+		})
+	}
+	return response{Result: map[string]interface{}{"list": list}}
+}
+
+func (s *Server) cancelTimingPush(form url.Values) response {
+	pushId, _ := strconv.ParseInt(form.Get("push_id"), 10, 64)
+	p := s.findPush(pushId)
+	if p == nil {
+		return paramError("push_id invalid")
+	}
+	if p.Status != xinge.STATUS_PENDING {
+		return response{Code: 19, Msg: "push is not a pending timing task"}
+	}
+	p.Status = xinge.STATUS_FAILED
+	p.Canceled = true
+	return response{}
+}
+
+func (s *Server) queryDeviceCount(form url.Values) response {
+	return response{Result: map[string]int{"device_num": len(s.tokens)}}
+}
+
+func (s *Server) queryInfoOfToken(form url.Values) response {
+	info, ok := s.tokens[form.Get("device_token")]
+	if !ok {
+		return response{Result: map[string]int64{"isReg": 0}}
+	}
+	return response{Result: map[string]int64{
+		"isReg":         1,
+		"connTimestamp": info.connTimestamp,
+		"msgsNum":       info.msgsNum,
+	}}
+}
+
+func (s *Server) queryTokensOfAccount(form url.Values) response {
+	tokens := make([]string, 0)
+	for token := range s.accounts[form.Get("account")] {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return response{Result: map[string][]string{"tokens": tokens}}
+}
+
+func (s *Server) deleteTokenOfAccount(form url.Values) response {
+	delete(s.accounts[form.Get("account")], form.Get("device_token"))
+	return response{}
+}
+
+func (s *Server) deleteAllTokensOfAccount(form url.Values) response {
+	delete(s.accounts, form.Get("account"))
+	return response{}
+}
+
+func (s *Server) queryTags(form url.Values) response {
+	start, _ := strconv.Atoi(form.Get("start"))
+	limit, _ := strconv.Atoi(form.Get("limit"))
+	if limit > TAG_QUERY_MAX_LIMIT {
+		return paramError("limit too large")
+	}
+	tags := s.sortedTags()
+	total := len(tags)
+
+	if start < 0 || start > total {
+		start = total
+	}
+	end := total
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	return response{Result: map[string]interface{}{"total": total, "tags": tags[start:end]}}
+}
+
+func (s *Server) queryTokenTags(form url.Values) response {
+	return response{Result: map[string][]string{"tags": s.tokenTags(form.Get("device_token"))}}
+}
+
+func (s *Server) queryTagTokenNum(form url.Values) response {
+	return response{Result: map[string]int{"device_num": len(s.tags[form.Get("tag")])}}
+}
+
+func decodeTagTokenList(form url.Values) ([][]string, bool) {
+	var pairs [][]string
+	if err := json.Unmarshal([]byte(form.Get("tag_token_list")), &pairs); err != nil || len(pairs) == 0 {
+		return nil, false
+	}
+	for _, pair := range pairs {
+		if len(pair) != 2 {
+			return nil, false
+		}
+	}
+	return pairs, true
+}
+
+func (s *Server) batchSetTag(form url.Values) response {
+	pairs, ok := decodeTagTokenList(form)
+	if !ok {
+		return paramError("tag_token_list invalid")
+	}
+	if len(pairs) > TAG_TOKEN_LIST_MAX_SIZE {
+		return paramError("tag_token_list too long")
+	}
+	for _, pair := range pairs {
+		tag, token := pair[0], pair[1]
+		s.registerToken(token)
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]bool)
+		}
+		s.tags[tag][token] = true
+	}
+	return response{}
+}
+
+func (s *Server) batchDelTag(form url.Values) response {
+	pairs, ok := decodeTagTokenList(form)
+	if !ok {
+		return paramError("tag_token_list invalid")
+	}
+	if len(pairs) > TAG_TOKEN_LIST_MAX_SIZE {
+		return paramError("tag_token_list too long")
+	}
+	for _, pair := range pairs {
+		tag, token := pair[0], pair[1]
+		delete(s.tags[tag], token)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+	return response{}
+}